@@ -0,0 +1,170 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes creates the indexes the handlers rely on. It is safe to run on every start.
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := map[string][]mongo.IndexModel{
+		"account_deletions": {
+			// One deletion job per account; a failed job is requeued rather than replaced
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"author_rewards": {
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "date", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"challenge_rolls": {
+			{
+				Keys:    bson.D{{Key: "email", Value: 1}, {Key: "date", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"gallery_posts": {
+			// One post per accepted challenge; guess posts carry no challenge_id
+			{
+				Keys: bson.D{{Key: "challenge_id", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"challenge_id": bson.M{"$exists": true}}),
+			},
+			{
+				Keys:    bson.D{{Key: "event_id", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			},
+			{
+				Keys:    bson.D{{Key: "group_id", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			},
+			{
+				Keys:    bson.D{{Key: "palette.name", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			},
+			{
+				Keys:    bson.D{{Key: "phash_bands", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			},
+			// Guess queues: newest first, optionally by difficulty
+			{
+				Keys: bson.D{{Key: "created_at", Value: -1}},
+				Options: options.Index().SetName("guess_queue_created_at").
+					SetPartialFilterExpression(bson.M{"guess": true}),
+			},
+			{
+				Keys: bson.D{{Key: "difficulty", Value: 1}, {Key: "created_at", Value: -1}},
+				Options: options.Index().SetName("guess_queue_difficulty_created_at").
+					SetPartialFilterExpression(bson.M{"guess": true}),
+			},
+			{
+				Keys:    bson.D{{Key: "rating", Value: 1}},
+				Options: options.Index().SetSparse(true),
+			},
+			{
+				Keys: bson.D{{Key: "review_status", Value: 1}, {Key: "created_at", Value: 1}},
+				Options: options.Index().
+					SetPartialFilterExpression(bson.M{"review_status": bson.M{"$exists": true}}),
+			},
+		},
+		"groups": {
+			{
+				Keys:    bson.D{{Key: "join_code", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"guess_views": {
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "post_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"moderation_cases": {
+			// One open case per post; resolved cases stay as history
+			{
+				Keys: bson.D{{Key: "post_id", Value: 1}},
+				Options: options.Index().SetUnique(true).
+					SetPartialFilterExpression(bson.M{"status": "open"}),
+			},
+			{
+				Keys: bson.D{{Key: "status", Value: 1}, {Key: "report_count", Value: -1}},
+			},
+		},
+		"moderation_log": {
+			{
+				Keys: bson.D{{Key: "created_at", Value: -1}},
+			},
+		},
+		"reports": {
+			{
+				Keys:    bson.D{{Key: "post_id", Value: 1}, {Key: "reporter_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"teams": {
+			{
+				Keys:    bson.D{{Key: "name", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"user_answers": {
+			// One answer per player per guess post, even when two requests race. Answers that
+			// raced the old count-then-insert check are removed by MigrateLegacyData first.
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "post_id", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"user_achievements": {
+			{
+				Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"user_hunts": {
+			// Restart checks and the per-player step photo lookup
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "hunt_id", Value: 1}}},
+		},
+	}
+
+	// Replaced indexes; dropping one that is already gone is not an error
+	stale := map[string][]string{
+		"gallery_posts": {"guess_created_at", "created_at_-1", "difficulty_1_created_at_-1"},
+	}
+	for collection, names := range stale {
+		for _, name := range names {
+			if _, err := DB.Collection(collection).Indexes().DropOne(ctx, name); err != nil && !isIndexNotFound(err) {
+				log.Println("Failed to drop index", name, "on", collection+":", err)
+			}
+		}
+	}
+
+	for collection, models := range indexes {
+		if _, err := DB.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			// Double answers would pay out twice, so running without this index is not an option
+			if collection == "user_answers" {
+				log.Fatal("Failed to create indexes on user_answers: ", err)
+			}
+			log.Println("Failed to create indexes on", collection+":", err)
+		}
+	}
+}
+
+// isIndexNotFound reports whether dropping an index failed because it does not exist.
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	return errors.As(err, &cmdErr) && (cmdErr.Code == 27 || cmdErr.Name == "IndexNotFound")
+}
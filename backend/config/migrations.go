@@ -0,0 +1,173 @@
+package config
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"photoquest/models"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MigrateLegacyData brings documents written by older versions up to date. It runs before
+// EnsureIndexes, whose unique indexes can only be built on clean data, and is safe to run on
+// every start.
+func MigrateLegacyData() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	foldLegacyScores(ctx)
+	dedupeUserAnswers(ctx)
+	flagGuessPosts(ctx)
+	rateGuessPosts(ctx)
+}
+
+// rateGuessPosts gives guess posts from before ratings existed the starting rating, so the
+// rating index serves them to players like any other post.
+func rateGuessPosts(ctx context.Context) {
+	res, err := DB.Collection("gallery_posts").UpdateMany(ctx,
+		bson.M{"guess": true, "rating": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"rating": models.GuessRatingStart}},
+	)
+	if err != nil {
+		log.Println("Failed to rate guess posts:", err)
+		return
+	}
+	if res.ModifiedCount > 0 {
+		log.Println("Gave", res.ModifiedCount, "guess posts the starting rating")
+	}
+}
+
+// flagGuessPosts sets the guess flag on guess posts uploaded before it existed, so the guess
+// queues and their indexes find them.
+func flagGuessPosts(ctx context.Context) {
+	res, err := DB.Collection("gallery_posts").UpdateMany(ctx,
+		bson.M{
+			"guess": bson.M{"$exists": false},
+			"$or": bson.A{
+				bson.M{"choices": bson.M{"$exists": true}},
+				bson.M{"answer_mode": "text"},
+			},
+		},
+		bson.M{"$set": bson.M{"guess": true}},
+	)
+	if err != nil {
+		log.Println("Failed to flag guess posts:", err)
+		return
+	}
+	if res.ModifiedCount > 0 {
+		log.Println("Flagged", res.ModifiedCount, "guess posts")
+	}
+}
+
+// foldLegacyScores moves the points the original gallery answer endpoint kept in users.score
+// into total_score, which every leaderboard reads. The old field is removed, so each user is
+// only folded once.
+func foldLegacyScores(ctx context.Context) {
+	res, err := DB.Collection("users").UpdateMany(ctx,
+		bson.M{"score": bson.M{"$exists": true}},
+		mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{"total_score": bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$total_score", 0}},
+				bson.M{"$ifNull": bson.A{"$score", 0}},
+			}}}}},
+			{{Key: "$unset", Value: "score"}},
+		},
+	)
+	if err != nil {
+		log.Println("Failed to fold legacy scores:", err)
+		return
+	}
+	if res.ModifiedCount > 0 {
+		log.Println("Folded legacy score into total_score for", res.ModifiedCount, "users")
+	}
+}
+
+// dedupeUserAnswers removes answers that raced past the old count-then-insert check, keeping
+// each player's first answer to a post, so the unique (user_id, post_id) index can be built.
+// What the removed answers paid is taken back from total_score: their recorded points, or
+// models.LegacyGuessPoints for correct answers that recorded none. It does nothing once the
+// index exists.
+func dedupeUserAnswers(ctx context.Context) {
+	answers := DB.Collection("user_answers")
+	specs, err := answers.Indexes().ListSpecifications(ctx)
+	if err != nil {
+		log.Println("Failed to list user_answers indexes:", err)
+		return
+	}
+	for _, spec := range specs {
+		if spec.Name == "user_id_1_post_id_1" {
+			return
+		}
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "answered_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{"user_id": "$user_id", "post_id": "$post_id"},
+			"answers": bson.M{"$push": bson.M{
+				"_id":        "$_id",
+				"is_correct": "$is_correct",
+				"points":     "$points",
+			}},
+			"count": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$match", Value: bson.M{"count": bson.M{"$gt": 1}}}},
+	}
+	cursor, err := answers.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		log.Println("Failed to find duplicate answers:", err)
+		return
+	}
+	var groups []struct {
+		ID struct {
+			UserID primitive.ObjectID `bson:"user_id"`
+		} `bson:"_id"`
+		Answers []struct {
+			ID        primitive.ObjectID `bson:"_id"`
+			IsCorrect bool               `bson:"is_correct"`
+			Points    *int               `bson:"points"`
+		} `bson:"answers"`
+	}
+	if err := cursor.All(ctx, &groups); err != nil {
+		log.Println("Failed to read duplicate answers:", err)
+		return
+	}
+
+	var extra []primitive.ObjectID
+	overpaid := map[primitive.ObjectID]int{}
+	for _, group := range groups {
+		for _, answer := range group.Answers[1:] {
+			extra = append(extra, answer.ID)
+			switch {
+			case answer.Points != nil:
+				overpaid[group.ID.UserID] += *answer.Points
+			case answer.IsCorrect:
+				overpaid[group.ID.UserID] += models.LegacyGuessPoints
+			}
+		}
+	}
+	if len(extra) == 0 {
+		return
+	}
+	res, err := answers.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": extra}})
+	if err != nil {
+		log.Println("Failed to remove duplicate answers:", err)
+		return
+	}
+	log.Println("Removed", res.DeletedCount, "duplicate answers before indexing user_answers")
+
+	for userID, points := range overpaid {
+		if points == 0 {
+			continue
+		}
+		_, err := DB.Collection("users").UpdateByID(ctx, userID, bson.M{"$inc": bson.M{"total_score": -points}})
+		if err != nil {
+			log.Println("Failed to take back duplicate answer points for", userID.Hex()+":", err)
+		}
+	}
+}
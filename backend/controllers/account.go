@@ -0,0 +1,659 @@
+package controllers
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+	"photoquest/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// deletedPlayerName replaces a deleted player's name where other players' records keep it.
+const deletedPlayerName = "Deleted player"
+
+// accountDeletionStep removes or anonymizes one kind of data and returns how many documents it touched.
+// Steps must be safe to run again, since a failed or interrupted job restarts from the top.
+type accountDeletionStep struct {
+	name string
+	run  func(ctx context.Context, user models.User) (int64, error)
+}
+
+// accountDeletionSteps run in order. The users document goes last so a failed job can still
+// find the account. Moderation cases and the moderation log only keep the player's ID and
+// stay as the audit trail.
+var accountDeletionSteps = []accountDeletionStep{
+	{"lock_account", lockDeletedAccount},
+	{"gallery_posts", deleteUserPosts},
+	{"likes", func(ctx context.Context, user models.User) (int64, error) {
+		res, err := config.DB.Collection("gallery_posts").UpdateMany(ctx,
+			bson.M{"likes": bson.M{"$in": userEmails(user)}},
+			bson.M{"$pull": bson.M{"likes": bson.M{"$in": userEmails(user)}}},
+		)
+		if err != nil {
+			return 0, err
+		}
+		return res.ModifiedCount, nil
+	}},
+	{"duels", anonymizeUserDuels},
+	{"teams", removeUserFromTeams},
+	{"team_challenges", func(ctx context.Context, user models.User) (int64, error) {
+		res, err := config.DB.Collection("team_challenges").UpdateMany(ctx,
+			bson.M{"contributors": user.ID},
+			bson.M{"$pull": bson.M{"contributors": user.ID}},
+		)
+		if err != nil {
+			return 0, err
+		}
+		return res.ModifiedCount, nil
+	}},
+	{"groups", removeUserFromGroups},
+	{"reports", deleteUserDocs("reports", "reporter_id")},
+	{"user_answers", deleteUserDocs("user_answers", "user_id")},
+	{"guess_views", deleteUserDocs("guess_views", "user_id")},
+	{"user_achievements", deleteUserDocs("user_achievements", "user_id")},
+	{"user_hunts", deleteUserHunts},
+	{"author_rewards", deleteUserDocs("author_rewards", "user_id")},
+	{"user_challenges", deleteUserDocs("user_challenges", "email")},
+	{"custom_challenges", deleteUserDocs("custom_challenges", "email")},
+	{"challenge_rolls", deleteUserDocs("challenge_rolls", "email")},
+	{"otps", deleteUserDocs("otps", "email")},
+	{"avatar", func(ctx context.Context, user models.User) (int64, error) {
+		if user.AvatarURL == "" {
+			return 0, nil
+		}
+		if err := utils.DeleteFromS3(user.AvatarURL); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}},
+	{"user", deleteLockedUser},
+}
+
+// userEmails lists the forms of the player's email that email-keyed records may use.
+// Likes and votes store the lower-cased address from the login token.
+func userEmails(user models.User) []string {
+	emails := []string{user.Email}
+	if lower := strings.ToLower(user.Email); lower != user.Email {
+		emails = append(emails, lower)
+	}
+	return emails
+}
+
+// deleteUserDocs builds a step that deletes every document in collection keyed to the player
+// by field, which is either an ID field or "email".
+func deleteUserDocs(collection, field string) func(ctx context.Context, user models.User) (int64, error) {
+	return func(ctx context.Context, user models.User) (int64, error) {
+		filter := bson.M{field: user.ID}
+		if field == "email" {
+			filter = bson.M{"email": bson.M{"$in": userEmails(user)}}
+		}
+		res, err := config.DB.Collection(collection).DeleteMany(ctx, filter)
+		if err != nil {
+			return 0, err
+		}
+		return res.DeletedCount, nil
+	}
+}
+
+// lockDeletedAccount marks the account as deleting, which currentUser, sign-in and password
+// resets refuse, and clears the password. DeleteAccount sets the mark as well; this step keeps
+// it for jobs resumed from before it did.
+func lockDeletedAccount(ctx context.Context, user models.User) (int64, error) {
+	res, err := config.DB.Collection("users").UpdateByID(ctx, user.ID, bson.M{"$set": bson.M{"deleting": true, "password": ""}})
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
+// deleteLockedUser removes the users document last. Requests that got past the lock before it
+// was set may still have added posts, so any left are deleted first.
+func deleteLockedUser(ctx context.Context, user models.User) (int64, error) {
+	if _, err := deleteUserPosts(ctx, user); err != nil {
+		return 0, err
+	}
+	res, err := config.DB.Collection("users").DeleteOne(ctx, bson.M{"_id": user.ID, "deleting": true})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// deleteUserPosts deletes the player's posts with everything hanging off them, photos included.
+func deleteUserPosts(ctx context.Context, user models.User) (int64, error) {
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"user_id": user.ID})
+	if err != nil {
+		return 0, err
+	}
+	var posts []models.GalleryPost
+	if err := cursor.All(ctx, &posts); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, post := range posts {
+		deleted, err := deletePost(ctx, post)
+		if err != nil {
+			return count, err
+		}
+		if deleted {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// deleteUserHunts deletes the player's hunt runs with their step photos, which deleting a
+// grouped hunt post leaves in place for the run.
+func deleteUserHunts(ctx context.Context, user models.User) (int64, error) {
+	cursor, err := config.DB.Collection("user_hunts").Find(ctx, bson.M{"user_id": user.ID})
+	if err != nil {
+		return 0, err
+	}
+	var runs []models.UserHunt
+	if err := cursor.All(ctx, &runs); err != nil {
+		return 0, err
+	}
+	for _, run := range runs {
+		for _, step := range run.Steps {
+			if step.ImageURL == "" {
+				continue
+			}
+			if err := utils.DeleteFromS3(step.ImageURL); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return deleteUserDocs("user_hunts", "user_id")(ctx, user)
+}
+
+// anonymizeUserDuels drops the player's votes, replaces their name on duels they fought and
+// expires the ones that can no longer finish.
+func anonymizeUserDuels(ctx context.Context, user models.User) (int64, error) {
+	duels := config.DB.Collection("duels")
+	emails := userEmails(user)
+
+	var count int64
+	res, err := duels.UpdateMany(ctx,
+		bson.M{"$or": []bson.M{
+			{"challenger_votes": bson.M{"$in": emails}},
+			{"opponent_votes": bson.M{"$in": emails}},
+		}},
+		bson.M{"$pull": bson.M{
+			"challenger_votes": bson.M{"$in": emails},
+			"opponent_votes":   bson.M{"$in": emails},
+		}},
+	)
+	if err != nil {
+		return 0, err
+	}
+	count += res.ModifiedCount
+
+	for _, side := range []string{"challenger", "opponent"} {
+		_, err = duels.UpdateMany(ctx,
+			bson.M{side + "_id": user.ID, "status": bson.M{"$in": []string{"pending", "active"}}},
+			bson.M{"$set": bson.M{"status": "expired"}},
+		)
+		if err != nil {
+			return count, err
+		}
+		res, err = duels.UpdateMany(ctx,
+			bson.M{side + "_id": user.ID, side + "_name": bson.M{"$ne": deletedPlayerName}},
+			bson.M{"$set": bson.M{side + "_name": deletedPlayerName}},
+		)
+		if err != nil {
+			return count, err
+		}
+		count += res.ModifiedCount
+	}
+	return count, nil
+}
+
+// removeUserFromTeams takes the player off their team and out of pending invites.
+func removeUserFromTeams(ctx context.Context, user models.User) (int64, error) {
+	teams := config.DB.Collection("teams")
+	cursor, err := teams.Find(ctx, bson.M{"members.user_id": user.ID})
+	if err != nil {
+		return 0, err
+	}
+	var memberOf []models.Team
+	if err := cursor.All(ctx, &memberOf); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, team := range memberOf {
+		if err := removeTeamMember(ctx, team, user.ID); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	res, err := teams.UpdateMany(ctx, bson.M{"invites": user.ID}, bson.M{"$pull": bson.M{"invites": user.ID}})
+	if err != nil {
+		return count, err
+	}
+	return count + res.ModifiedCount, nil
+}
+
+// removeUserFromGroups takes the player out of every group. Where they were the only admin,
+// the longest serving remaining member becomes admin.
+func removeUserFromGroups(ctx context.Context, user models.User) (int64, error) {
+	groups := config.DB.Collection("groups")
+	cursor, err := groups.Find(ctx, bson.M{"members.user_id": user.ID})
+	if err != nil {
+		return 0, err
+	}
+	var memberOf []models.Group
+	if err := cursor.All(ctx, &memberOf); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, group := range memberOf {
+		admins := 0
+		var successor *models.GroupMember
+		for i, member := range group.Members {
+			if member.Role == "admin" && member.UserID != user.ID {
+				admins++
+			}
+			if member.UserID != user.ID && successor == nil {
+				successor = &group.Members[i]
+			}
+		}
+		if admins == 0 && successor != nil {
+			_, err := groups.UpdateOne(ctx,
+				bson.M{"_id": group.ID, "members.user_id": successor.UserID},
+				bson.M{"$set": bson.M{"members.$.role": "admin"}},
+			)
+			if err != nil {
+				return count, err
+			}
+		}
+		if err := removeGroupMember(ctx, group, user.ID); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// runAccountDeletion works through a queued deletion job, recording each step as it finishes.
+func runAccountDeletion(jobID primitive.ObjectID) {
+	jobs := config.DB.Collection("account_deletions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	var job models.AccountDeletion
+	err := jobs.FindOneAndUpdate(ctx,
+		bson.M{"_id": jobID, "status": "queued"},
+		bson.M{"$set": bson.M{"status": "running", "started_at": time.Now(), "steps": []models.AccountDeletionStep{}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	cancel()
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			fmt.Println("Failed to start account deletion", jobID.Hex()+":", err)
+		}
+		return
+	}
+
+	finish := func(set bson.M) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		set["finished_at"] = time.Now()
+		if _, err := jobs.UpdateByID(ctx, jobID, bson.M{"$set": set}); err != nil {
+			fmt.Println("Failed to update account deletion", jobID.Hex()+":", err)
+		}
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+	var user models.User
+	err = config.DB.Collection("users").FindOne(ctx, bson.M{"_id": job.UserID}).Decode(&user)
+	cancel()
+	if err == mongo.ErrNoDocuments {
+		// The account went on an earlier run that stopped before recording it
+		finish(bson.M{"status": "completed"})
+		return
+	}
+	if err != nil {
+		finish(bson.M{"status": "failed", "error": "Failed to load account"})
+		return
+	}
+
+	for _, step := range accountDeletionSteps {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+		count, err := step.run(ctx, user)
+		cancel()
+		if err != nil {
+			fmt.Println("Account deletion", jobID.Hex(), "failed at", step.name+":", err)
+			finish(bson.M{"status": "failed", "error": "Failed at step " + step.name})
+			return
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), 10*time.Second)
+		_, err = jobs.UpdateByID(ctx, jobID, bson.M{"$push": bson.M{"steps": models.AccountDeletionStep{
+			Name:       step.name,
+			Count:      count,
+			FinishedAt: time.Now(),
+		}}})
+		cancel()
+		if err != nil {
+			fmt.Println("Failed to record account deletion step", step.name+":", err)
+		}
+	}
+	finish(bson.M{"status": "completed"})
+}
+
+// ResumeAccountDeletions restarts deletion jobs that were queued or cut off by a restart.
+// It runs once at startup, before any job of this process can be running.
+func ResumeAccountDeletions() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	jobs := config.DB.Collection("account_deletions")
+	_, err := jobs.UpdateMany(ctx, bson.M{"status": "running"}, bson.M{"$set": bson.M{"status": "queued"}})
+	if err != nil {
+		fmt.Println("Failed to requeue account deletions:", err)
+		return
+	}
+
+	cursor, err := jobs.Find(ctx, bson.M{"status": "queued"}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		fmt.Println("Failed to load account deletions:", err)
+		return
+	}
+	var queued []models.AccountDeletion
+	if err := cursor.All(ctx, &queued); err != nil {
+		fmt.Println("Failed to load account deletions:", err)
+		return
+	}
+	for _, job := range queued {
+		go runAccountDeletion(job.ID)
+	}
+}
+
+// DeleteAccount queues the deletion of the caller's account and all their data.
+// A failed job is retried by calling this again.
+// DELETE /profile
+func DeleteAccount(c *gin.Context) {
+	userID, _, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Lock the account now rather than when the job gets to it
+	_, err := config.DB.Collection("users").UpdateByID(ctx, userID, bson.M{"$set": bson.M{"deleting": true}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	jobs := config.DB.Collection("account_deletions")
+	var job models.AccountDeletion
+	err = jobs.FindOneAndUpdate(ctx,
+		bson.M{"user_id": userID, "status": "failed"},
+		bson.M{"$set": bson.M{"status": "queued", "requested_at": time.Now()}, "$unset": bson.M{"error": "", "finished_at": ""}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		job = models.AccountDeletion{
+			UserID:      userID,
+			Status:      "queued",
+			Steps:       []models.AccountDeletionStep{},
+			RequestedAt: time.Now(),
+		}
+		var res *mongo.InsertOneResult
+		res, err = jobs.InsertOne(ctx, job)
+		if mongo.IsDuplicateKeyError(err) {
+			// Already queued, running or done
+			if err := jobs.FindOne(ctx, bson.M{"user_id": userID}).Decode(&job); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load account deletion"})
+				return
+			}
+			c.JSON(http.StatusAccepted, gin.H{"message": "Account deletion already requested", "job": job})
+			return
+		}
+		if err == nil {
+			job.ID = res.InsertedID.(primitive.ObjectID)
+		}
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete account"})
+		return
+	}
+
+	go runAccountDeletion(job.ID)
+
+	c.JSON(http.StatusAccepted, gin.H{"message": "Account deletion started", "job": job})
+}
+
+// GetAccountDeletion shows the progress of the caller's account deletion
+// GET /profile/deletion
+func GetAccountDeletion(c *gin.Context) {
+	userID, _, ok := tokenUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var job models.AccountDeletion
+	err := config.DB.Collection("account_deletions").FindOne(ctx, bson.M{"user_id": userID}).Decode(&job)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No account deletion requested"})
+		return
+	}
+	c.JSON(http.StatusOK, job)
+}
+
+// exportSection is one collection's worth of the player's data in an export archive.
+type exportSection struct {
+	name       string
+	collection string
+	filter     bson.M
+	projection bson.M
+}
+
+// exportPhoto is a stored photo copied into an export archive.
+type exportPhoto struct {
+	URL   string `json:"url"`
+	File  string `json:"file,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// exportFileName picks the archive path for a photo, keeping the stored file's extension.
+func exportFileName(prefix, url string) string {
+	ext := strings.ToLower(path.Ext(url))
+	if ext == "" || len(ext) > 5 {
+		ext = ".jpg"
+	}
+	return prefix + ext
+}
+
+// copyPhoto downloads a stored photo into the archive.
+func copyPhoto(ctx context.Context, client *http.Client, archive *zip.Writer, name, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	file, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(file, io.LimitReader(resp.Body, 32<<20))
+	return err
+}
+
+// ExportAccount downloads everything stored about the caller as a zip archive: data.json with
+// a section per collection, and their avatar, post and hunt step photos under photos/.
+// GET /profile/export
+func ExportAccount(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	emails := bson.M{"$in": userEmails(user)}
+
+	sections := []exportSection{
+		{"profile", "users", bson.M{"_id": userID}, bson.M{"password": 0}},
+		{"posts", "gallery_posts", bson.M{"user_id": userID}, bson.M{"phash_bands": 0}},
+		{"liked_posts", "gallery_posts", bson.M{"likes": emails}, bson.M{"_id": 1, "image_url": 1, "user_name": 1}},
+		{"challenges", "user_challenges", bson.M{"email": emails}, nil},
+		{"custom_challenges", "custom_challenges", bson.M{"email": emails}, nil},
+		{"challenge_rolls", "challenge_rolls", bson.M{"email": emails}, nil},
+		{"answers", "user_answers", bson.M{"user_id": userID}, nil},
+		{"guess_views", "guess_views", bson.M{"user_id": userID}, nil},
+		{"achievements", "user_achievements", bson.M{"user_id": userID}, nil},
+		{"hunts", "user_hunts", bson.M{"user_id": userID}, nil},
+		{"author_rewards", "author_rewards", bson.M{"user_id": userID}, nil},
+		{"reports", "reports", bson.M{"reporter_id": userID}, nil},
+		{"moderation_cases", "moderation_cases", bson.M{"post_owner_id": userID}, bson.M{"resolved_by": 0}},
+		{"duels", "duels", bson.M{"$or": []bson.M{{"challenger_id": userID}, {"opponent_id": userID}}}, bson.M{"challenger_votes": 0, "opponent_votes": 0}},
+		{"teams", "teams", bson.M{"members.user_id": userID}, bson.M{"_id": 1, "name": 1, "members.$": 1}},
+		{"groups", "groups", bson.M{"members.user_id": userID}, bson.M{"_id": 1, "name": 1, "members.$": 1}},
+	}
+
+	// Decode nested documents as maps so they come out as JSON objects
+	collectionOptions := options.Collection().SetBSONOptions(&options.BSONOptions{DefaultDocumentM: true})
+	data := gin.H{"exported_at": time.Now()}
+	for _, section := range sections {
+		findOptions := options.Find()
+		if section.projection != nil {
+			findOptions.SetProjection(section.projection)
+		}
+		cursor, err := config.DB.Collection(section.collection, collectionOptions).Find(ctx, section.filter, findOptions)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export " + section.name})
+			return
+		}
+		docs := []bson.M{}
+		if err := cursor.All(ctx, &docs); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export " + section.name})
+			return
+		}
+		// Likes are other players' email addresses, so the export only says how many there were
+		if section.name == "posts" {
+			for _, doc := range docs {
+				likes, _ := doc["likes"].(bson.A)
+				doc["likes_count"] = len(likes)
+				delete(doc, "likes")
+			}
+		}
+		data[section.name] = docs
+	}
+
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetProjection(bson.M{"_id": 1, "image_url": 1, "images": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export photos"})
+		return
+	}
+	var posts []models.GalleryPost
+	if err := cursor.All(ctx, &posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export photos"})
+		return
+	}
+	cursor, err = config.DB.Collection("user_hunts").Find(ctx, bson.M{"user_id": userID},
+		options.Find().SetProjection(bson.M{"_id": 1, "steps.image_url": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export photos"})
+		return
+	}
+	var runs []models.UserHunt
+	if err := cursor.All(ctx, &runs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export photos"})
+		return
+	}
+
+	c.Header("Content-Type", "application/zip")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="photoquest-export-%s.zip"`, userID.Hex()))
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	client := &http.Client{Timeout: 30 * time.Second}
+	var photos []exportPhoto
+	copied := map[string]bool{}
+	addPhoto := func(prefix, url string) {
+		// Hunt step photos are also the photos of the run's grouped post
+		if url == "" || copied[url] {
+			return
+		}
+		copied[url] = true
+		photo := exportPhoto{URL: url, File: exportFileName(prefix, url)}
+		if err := copyPhoto(ctx, client, archive, photo.File, url); err != nil {
+			fmt.Println("Failed to export photo", url+":", err)
+			photo.File, photo.Error = "", "Photo could not be downloaded"
+		}
+		photos = append(photos, photo)
+	}
+	if user.AvatarURL != "" {
+		addPhoto("photos/avatar", user.AvatarURL)
+	}
+	for _, post := range posts {
+		// Grouped hunt posts list their cover among Images as well
+		images := []string{post.ImageURL}
+		for _, image := range post.Images {
+			if image != post.ImageURL {
+				images = append(images, image)
+			}
+		}
+		for i, image := range images {
+			addPhoto(fmt.Sprintf("photos/posts/%s-%d", post.ID.Hex(), i+1), image)
+		}
+	}
+	for _, run := range runs {
+		for i, step := range run.Steps {
+			addPhoto(fmt.Sprintf("photos/hunts/%s-%d", run.ID.Hex(), i+1), step.ImageURL)
+		}
+	}
+	data["photos"] = photos
+
+	file, err := archive.Create("data.json")
+	if err == nil {
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(data)
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		// The response has started, so all that is left is to cut the archive short
+		fmt.Println("Failed to write account export:", err)
+	}
+}
@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// achievementRules is the achievements catalog. Adding a rule here is enough for it to be
+// evaluated live and picked up by the backfill.
+var achievementRules = []models.Achievement{
+	{Key: "first_upload", Name: "First Shot", Description: "Upload your first photo", Metric: "uploads", Threshold: 1},
+	{Key: "uploads_25", Name: "Shutterbug", Description: "Upload 25 photos", Metric: "uploads", Threshold: 25},
+	{Key: "hard_10", Name: "Hardcore", Description: "Complete 10 hard challenges", Metric: "hard_challenges", Threshold: 10},
+	{Key: "likes_100", Name: "Crowd Favourite", Description: "Receive 100 likes", Metric: "likes_received", Threshold: 100},
+	{Key: "streak_7", Name: "On a Roll", Description: "Complete a challenge 7 days in a row", Metric: "streak_days", Threshold: 7},
+	{Key: "guesses_50", Name: "Sharp Eye", Description: "Answer 50 guesses correctly", Metric: "correct_guesses", Threshold: 50},
+}
+
+// loadAchievementMetrics returns, per metric, the ascending times at which the user's value
+// went up by one, so the n-th entry is when the metric first reached n.
+func loadAchievementMetrics(ctx context.Context, userID primitive.ObjectID) (map[string][]time.Time, error) {
+	metrics := map[string][]time.Time{}
+
+	findOptions := options.Find().SetProjection(bson.M{
+		"created_at": 1,
+		"task":       1,
+		"difficulty": 1,
+		"likes":      1,
+	})
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, publishedPosts(bson.M{"user_id": userID}), findOptions)
+	if err != nil {
+		return nil, err
+	}
+	var posts []models.GalleryPost
+	if err := cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+
+	var submissions []time.Time
+	for _, post := range posts {
+		metrics["uploads"] = append(metrics["uploads"], post.CreatedAt)
+		// Likes carry no timestamp, so the post's creation time stands in for them
+		for range post.Likes {
+			metrics["likes_received"] = append(metrics["likes_received"], post.CreatedAt)
+		}
+		if post.Task != "" {
+			submissions = append(submissions, post.CreatedAt)
+			if post.Difficulty == "hard" {
+				metrics["hard_challenges"] = append(metrics["hard_challenges"], post.CreatedAt)
+			}
+		}
+	}
+	metrics["streak_days"] = streakMilestones(submissions)
+
+	cursor, err = config.DB.Collection("user_answers").Find(ctx, bson.M{"user_id": userID, "is_correct": true})
+	if err != nil {
+		return nil, err
+	}
+	var answers []models.UserAnswer
+	if err := cursor.All(ctx, &answers); err != nil {
+		return nil, err
+	}
+	for _, answer := range answers {
+		metrics["correct_guesses"] = append(metrics["correct_guesses"], time.Unix(answer.AnsweredAt, 0))
+	}
+
+	for _, events := range metrics {
+		sort.Slice(events, func(i, j int) bool { return events[i].Before(events[j]) })
+	}
+	return metrics, nil
+}
+
+// streakMilestones returns the time each new longest run of consecutive submission days was reached.
+func streakMilestones(submissions []time.Time) []time.Time {
+	sort.Slice(submissions, func(i, j int) bool { return submissions[i].Before(submissions[j]) })
+
+	var milestones []time.Time
+	run := 0
+	var lastDay time.Time
+	for _, submittedAt := range submissions {
+		day := time.Date(submittedAt.Year(), submittedAt.Month(), submittedAt.Day(), 0, 0, 0, 0, submittedAt.Location())
+		switch {
+		case run > 0 && day.Equal(lastDay):
+			continue
+		case run > 0 && day.Equal(lastDay.AddDate(0, 0, 1)):
+			run++
+		default:
+			run = 1
+		}
+		lastDay = day
+		if run > len(milestones) {
+			milestones = append(milestones, submittedAt)
+		}
+	}
+	return milestones
+}
+
+// unlockAchievements stores every achievement the user qualifies for and has not unlocked yet,
+// returning the newly unlocked ones. With backfill set, the unlock time is when the threshold
+// was historically reached instead of now.
+func unlockAchievements(ctx context.Context, userID primitive.ObjectID, backfill bool) ([]models.UserAchievement, error) {
+	metrics, err := loadAchievementMetrics(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	unlocked := []models.UserAchievement{}
+	for _, rule := range achievementRules {
+		events := metrics[rule.Metric]
+		if len(events) < rule.Threshold {
+			continue
+		}
+
+		achievement := models.UserAchievement{
+			UserID:     userID,
+			Key:        rule.Key,
+			Name:       rule.Name,
+			UnlockedAt: time.Now(),
+		}
+		if backfill {
+			achievement.UnlockedAt = events[rule.Threshold-1]
+		}
+
+		res, err := config.DB.Collection("user_achievements").UpdateOne(ctx,
+			bson.M{"user_id": userID, "key": rule.Key},
+			bson.M{"$setOnInsert": achievement},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return unlocked, err
+		}
+		if res.UpsertedCount > 0 {
+			unlocked = append(unlocked, achievement)
+		}
+	}
+	return unlocked, nil
+}
+
+// checkAchievements evaluates the rules after a scoring event. Failures are logged rather than
+// returned because the event itself has already been saved.
+func checkAchievements(ctx context.Context, userID primitive.ObjectID) []models.UserAchievement {
+	unlocked, err := unlockAchievements(ctx, userID, false)
+	if err != nil {
+		fmt.Println("Failed to evaluate achievements:", err)
+	}
+	return unlocked
+}
+
+// getUserAchievements returns the achievements the user has unlocked, oldest first.
+func getUserAchievements(ctx context.Context, userID primitive.ObjectID) ([]models.UserAchievement, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "unlocked_at", Value: 1}})
+	cursor, err := config.DB.Collection("user_achievements").Find(ctx, bson.M{"user_id": userID}, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	achievements := []models.UserAchievement{}
+	if err := cursor.All(ctx, &achievements); err != nil {
+		return nil, err
+	}
+	return achievements, nil
+}
+
+// GetMyAchievements lists the whole catalog with the caller's progress
+// GET /profile/achievements
+func GetMyAchievements(c *gin.Context) {
+	userID := c.MustGet("user_id").(string)
+	objID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ObjectID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	metrics, err := loadAchievementMetrics(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load achievement progress"})
+		return
+	}
+	unlocked, err := getUserAchievements(ctx, objID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+	unlockedAt := map[string]time.Time{}
+	for _, achievement := range unlocked {
+		unlockedAt[achievement.Key] = achievement.UnlockedAt
+	}
+
+	var result []gin.H
+	for _, rule := range achievementRules {
+		progress := len(metrics[rule.Metric])
+		if progress > rule.Threshold {
+			progress = rule.Threshold
+		}
+		item := gin.H{
+			"key":         rule.Key,
+			"name":        rule.Name,
+			"description": rule.Description,
+			"progress":    progress,
+			"threshold":   rule.Threshold,
+			"unlocked":    false,
+		}
+		if at, ok := unlockedAt[rule.Key]; ok {
+			item["unlocked"] = true
+			item["unlocked_at"] = at
+		}
+		result = append(result, item)
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// BackfillAchievements unlocks achievements earned before the engine existed
+// POST /admin/achievements/backfill
+func BackfillAchievements(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := config.DB.Collection("users").Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse users"})
+		return
+	}
+
+	unlockedCount := 0
+	failed := 0
+	for _, user := range users {
+		userCtx, userCancel := context.WithTimeout(context.Background(), 10*time.Second)
+		unlocked, err := unlockAchievements(userCtx, user.ID, true)
+		userCancel()
+		if err != nil {
+			fmt.Println("Failed to backfill achievements for", user.ID.Hex()+":", err)
+			failed++
+		}
+		unlockedCount += len(unlocked)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Achievements backfilled",
+		"users":    len(users),
+		"unlocked": unlockedCount,
+		"failed":   failed,
+	})
+}
@@ -203,7 +203,7 @@ func Login(c *gin.Context) {
 		},
 	}).Decode(&user)
 
-	if err != nil || !user.Verified {
+	if err != nil || !user.Verified || user.Deleting {
 		c.JSON(401, gin.H{"error": "User not found or not verified"})
 		return
 	}
@@ -237,7 +237,7 @@ func ForgotPassword(c *gin.Context) {
 	// Check if user exists
 	var user models.User
 	err := config.DB.Collection("users").FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
-	if err != nil {
+	if err != nil || user.Deleting {
 		c.JSON(400, gin.H{"error": "User not found"})
 		return
 	}
@@ -303,7 +303,7 @@ func ResetPassword(c *gin.Context) {
 	var user models.User
 	usersCollection := config.DB.Collection("users")
 	err := usersCollection.FindOne(ctx, bson.M{"email": req.Email}).Decode(&user)
-	if err != nil {
+	if err != nil || user.Deleting {
 		c.JSON(400, gin.H{"error": "User not found"})
 		return
 	}
@@ -315,14 +315,19 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
-	_, err = usersCollection.UpdateOne(ctx,
-		bson.M{"email": req.Email},
+	// The filter keeps a deletion that started meanwhile from being undone
+	res, err := usersCollection.UpdateOne(ctx,
+		bson.M{"email": req.Email, "deleting": bson.M{"$ne": true}},
 		bson.M{"$set": bson.M{"password": string(hashed)}},
 	)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to update password"})
 		return
 	}
+	if res.MatchedCount == 0 {
+		c.JSON(400, gin.H{"error": "User not found"})
+		return
+	}
 
 	c.JSON(200, gin.H{"message": "Password reset successful"})
 }
@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"io"
 	"math/rand"
 	"net/http"
 	"strings"
@@ -16,12 +17,19 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // Postman: All routes work fine สังสัยตรง uploadcustomchallenge นิดนึงตรงที่ gallery_post
 // RollChallenge
 // GET /challenge/roll?mode=easy
 func RollChallenge(c *gin.Context) {
+	_, email, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
 	mode := c.Query("mode")
 	if mode == "" {
 		c.JSON(400, gin.H{"error": "Missing mode"})
@@ -31,42 +39,120 @@ func RollChallenge(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Fetch all challenges in the given mode
-	challengesCol := config.DB.Collection("challenges")
-	cursor, err := challengesCol.Find(ctx, bson.M{"mode": mode})
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to fetch challenges"})
+	rules := loadChallengeRules(ctx)
+	if _, ok := rules.Modes[mode]; !ok {
+		c.JSON(400, gin.H{"error": "Unknown mode"})
 		return
 	}
 
-	var challenges []models.Challenge
-	if err := cursor.All(ctx, &challenges); err != nil || len(challenges) == 0 {
-		c.JSON(500, gin.H{"error": "No challenges available"})
+	// A running event replaces the regular pool for the modes it has prompts for
+	var response gin.H
+	event, err := activeEvent(ctx)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch events"})
+		return
+	}
+	if event != nil {
+		if pool := eventPrompts(event, mode); len(pool) > 0 {
+			random := pool[rand.Intn(len(pool))]
+			response = gin.H{
+				"prompt":            random.Prompt,
+				"mode":              random.Mode,
+				"event_id":          event.ID.Hex(),
+				"event_name":        event.Name,
+				"points_multiplier": event.PointsMultiplier,
+				"geofence":          random.Geofence,
+				"colors":            random.Colors,
+			}
+		}
+	}
+
+	if response == nil {
+		// Fetch all challenges in the given mode
+		challengesCol := config.DB.Collection("challenges")
+		cursor, err := challengesCol.Find(ctx, bson.M{"mode": mode})
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Failed to fetch challenges"})
+			return
+		}
+
+		var challenges []models.Challenge
+		if err := cursor.All(ctx, &challenges); err != nil || len(challenges) == 0 {
+			c.JSON(500, gin.H{"error": "No challenges available"})
+			return
+		}
+
+		random := challenges[rand.Intn(len(challenges))]
+		response = gin.H{
+			"prompt":   random.Prompt,
+			"mode":     random.Mode,
+			"geofence": random.Geofence,
+			"colors":   random.Colors,
+		}
+	}
+
+	// Every roll counts against the day's allowance: one per daily slot plus the rerolls. It is
+	// only counted once a prompt was picked, so a failed lookup doesn't use one up.
+	maxRolls := int64(rules.DailyLimit + rules.RerollsPerDay)
+	today := time.Now().Format("2006-01-02")
+	var rolls struct {
+		Count int64 `bson:"count"`
+	}
+	err = config.DB.Collection("challenge_rolls").FindOneAndUpdate(ctx,
+		bson.M{"email": email, "date": today, "count": bson.M{"$lt": maxRolls}},
+		bson.M{"$inc": bson.M{"count": 1}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&rolls)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(429, gin.H{
+			"error":     "You've used all your rolls for today",
+			"max_rolls": maxRolls,
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
 		return
 	}
 
-	random := challenges[rand.Intn(len(challenges))]
-	c.JSON(200, random)
+	response["rolls_remaining"] = maxRolls - rolls.Count
+	c.JSON(200, response)
 }
 
 // AcceptChallenge
 // POST /challenge/accept
 func AcceptChallenge(c *gin.Context) {
+	_, email, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
 	var req models.UserChallenge
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(400, gin.H{"error": "Invalid input"})
 		return
 	}
 
-	// Normalize
-	req.Email = strings.ToLower(req.Email)
+	// Normalize; the challenge always belongs to the caller, whatever email the body names
+	req.ID = primitive.NilObjectID
+	req.Email = email
 	req.Date = time.Now().Format("2006-01-02")
 	req.Status = "accepted"
+	req.AcceptedAt = time.Now()
+	req.ClosedAt = time.Time{}
+	req.ImageURL = ""
+	// Duel, team and group challenges are created through their own endpoints
+	req.DuelID = nil
+	req.TeamChallengeID = nil
+	req.GroupID = nil
+	req.Geofence = nil
+	req.Colors = nil
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	userChallenges := config.DB.Collection("user_challenges")
+	expireStaleChallenges(ctx, req.Email)
 
 	// Check for existing challenge with the same prompt today
 	duplicateFilter := bson.M{
@@ -84,28 +170,83 @@ func AcceptChallenge(c *gin.Context) {
 		return
 	}
 
-	// Check if user has accepted more than 5 challenges today
+	rules := loadChallengeRules(ctx)
+	modeRules, ok := rules.Modes[req.Mode]
+	if !ok {
+		c.JSON(400, gin.H{"error": "Unknown mode"})
+		return
+	}
+
+	// Event prompts only count as such while the event runs and the prompt is in its pool
+	if req.EventID != nil {
+		event, err := activeEvent(ctx)
+		if err != nil {
+			c.JSON(500, gin.H{"error": "Database error"})
+			return
+		}
+		inPool := false
+		if event != nil && event.ID == *req.EventID {
+			for _, prompt := range eventPrompts(event, req.Mode) {
+				if prompt.Prompt == req.Prompt {
+					inPool = true
+					req.Geofence = prompt.Geofence
+					req.Colors = prompt.Colors
+					break
+				}
+			}
+		}
+		if !inPool {
+			c.JSON(400, gin.H{"error": "This prompt is not part of a running event"})
+			return
+		}
+	} else {
+		if prompt := poolPrompt(ctx, req.Prompt, req.Mode); prompt != nil {
+			req.Geofence = prompt.Geofence
+			req.Colors = prompt.Colors
+		}
+	}
+
+	// Check the daily limit across all modes, then the limit for this mode
 	limitFilter := bson.M{
 		"email":  req.Email,
 		"date":   req.Date,
-		"status": "accepted",
+		"status": bson.M{"$in": dailySlotStatuses},
 	}
 	count, err := userChallenges.CountDocuments(ctx, limitFilter)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Database error"})
 		return
 	}
-	if count >= 5 {
+	if count >= int64(rules.DailyLimit) {
 		c.JSON(403, gin.H{
-			"error":            "You've already accepted 5 challenges today",
+			"error":            fmt.Sprintf("You've already accepted %d challenges today", rules.DailyLimit),
 			"daily_challenges": count,
-			"max_challenges":   5,
+			"max_challenges":   rules.DailyLimit,
+		})
+		return
+	}
+
+	modeCount, err := userChallenges.CountDocuments(ctx, bson.M{
+		"email":  req.Email,
+		"date":   req.Date,
+		"mode":   req.Mode,
+		"status": bson.M{"$in": dailySlotStatuses},
+	})
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Database error"})
+		return
+	}
+	if modeCount >= int64(modeRules.DailyLimit) {
+		c.JSON(403, gin.H{
+			"error":            fmt.Sprintf("You've already accepted %d %s challenges today", modeRules.DailyLimit, req.Mode),
+			"daily_challenges": count,
+			"max_challenges":   rules.DailyLimit,
 		})
 		return
 	}
 
 	// Insert new challenge
-	_, err = userChallenges.InsertOne(ctx, req)
+	res, err := userChallenges.InsertOne(ctx, req)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to save challenge"})
 		return
@@ -116,9 +257,11 @@ func AcceptChallenge(c *gin.Context) {
 
 	c.JSON(200, gin.H{
 		"message":              "Challenge accepted",
+		"challenge_id":         res.InsertedID,
 		"daily_challenges":     newCount,
-		"max_challenges":       5,
-		"remaining_challenges": 5 - newCount,
+		"max_challenges":       rules.DailyLimit,
+		"remaining_challenges": int64(rules.DailyLimit) - newCount,
+		"submit_before":        req.AcceptedAt.Add(rules.SubmissionWindow()),
 	})
 }
 
@@ -134,12 +277,14 @@ func GetUserChallengeStatus(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	expireStaleChallenges(ctx, strings.ToLower(email))
+
 	// Check current day's challenges
 	today := time.Now().Format("2006-01-02")
 	filter := bson.M{
-		"email":  email,
+		"email":  strings.ToLower(email),
 		"date":   today,
-		"status": "accepted",
+		"status": bson.M{"$in": dailySlotStatuses},
 	}
 
 	count, err := config.DB.Collection("user_challenges").CountDocuments(ctx, filter)
@@ -148,23 +293,18 @@ func GetUserChallengeStatus(c *gin.Context) {
 		return
 	}
 
-	// Check if it's a new day and reset if needed
-	if count == 0 {
-		// Optional: You could clear old records here
-		c.JSON(200, gin.H{
-			"daily_challenges":     0,
-			"max_challenges":       5,
-			"remaining_challenges": 5,
-			"is_reset":             true,
-		})
-		return
+	rules := loadChallengeRules(ctx)
+	remaining := int64(rules.DailyLimit) - count
+	if remaining < 0 {
+		remaining = 0
 	}
 
+	// is_reset means nothing has been accepted yet today
 	c.JSON(200, gin.H{
 		"daily_challenges":     count,
-		"max_challenges":       5,
-		"remaining_challenges": 5 - count,
-		"is_reset":             false,
+		"max_challenges":       rules.DailyLimit,
+		"remaining_challenges": remaining,
+		"is_reset":             count == 0,
 	})
 }
 
@@ -192,22 +332,58 @@ func UploadCustomChallenge(c *gin.Context) {
 
 	// Get form values
 	email := c.PostForm("email")
-	correctIndex := c.PostForm("correct_index")
 	prompt := c.PostForm("prompt")
-	difficulty := c.PostForm("difficulty")
-	choices := []string{
-		c.PostForm("choice1"),
-		c.PostForm("choice2"),
-		c.PostForm("choice3"),
-		c.PostForm("choice4"),
-	}
-
-	// ❗ Validate choices (must not be empty)
-	for i, choice := range choices {
-		if strings.TrimSpace(choice) == "" {
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("choice%d is required", i+1)})
+	difficulty := strings.ToLower(strings.TrimSpace(c.PostForm("difficulty")))
+	if difficulty == "" {
+		difficulty = "medium"
+	}
+	if difficulty != "easy" && difficulty != "medium" && difficulty != "hard" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "difficulty must be easy, medium or hard"})
+		return
+	}
+	groupIDHex := c.PostForm("group_id")
+	hint := strings.TrimSpace(c.PostForm("hint"))
+	if len(hint) > maxGuessHintLength {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("hint must be at most %d characters", maxGuessHintLength)})
+		return
+	}
+	answerMode := c.DefaultPostForm("answer_mode", "choice")
+	var choices, answers []string
+	var correctIdx int
+	switch answerMode {
+	case "choice":
+		var msg string
+		if choices, correctIdx, msg = guessChoicesFromForm(c); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return
+		}
+	case "text":
+		if answers = guessAnswersFromForm(c); len(answers) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "answer is required for text guess posts"})
+			return
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "answer_mode must be choice or text"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// An optional group_id keeps the guess post inside that group
+	var groupID *primitive.ObjectID
+	if groupIDHex != "" {
+		id, err := primitive.ObjectIDFromHex(groupIDHex)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group_id"})
+			return
+		}
+		count, err := config.DB.Collection("groups").CountDocuments(ctx, bson.M{"_id": id, "members.user_id": userID})
+		if err != nil || count == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of that group"})
 			return
 		}
+		groupID = &id
 	}
 
 	// Get uploaded file
@@ -218,20 +394,38 @@ func UploadCustomChallenge(c *gin.Context) {
 	}
 	src, _ := header.Open()
 	defer src.Close()
+	photo, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded photo"})
+		return
+	}
+
+	rules := loadChallengeRules(ctx)
+	fingerprint := fingerprintPhoto(ctx, photo, rules.DuplicateMaxDistance)
+	if rejectDuplicate(c, fingerprint, rules.DuplicatePolicy, userID) {
+		return
+	}
+
+	// Get user data from database
+	var user models.User
+	err = config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		return
+	}
 
 	// Upload to S3
-	imageURL, err := utils.UploadToS3(src, header)
+	imageURL, err := utils.UploadBytesToS3(photo, header)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to S3", "detail": err.Error()})
 		return
 	}
 
-	// Validate correct_index
-	var correctIdx int
-	fmt.Sscanf(correctIndex, "%d", &correctIdx)
-	if correctIdx < 0 || correctIdx > 3 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid correct_index"})
-		return
+	// The upload is only kept once a post uses it
+	discardPhoto := func() {
+		if err := utils.DeleteFromS3(imageURL); err != nil {
+			fmt.Println("Failed to delete unused upload:", err)
+		}
 	}
 
 	// Create custom challenge document
@@ -243,46 +437,56 @@ func UploadCustomChallenge(c *gin.Context) {
 		CreatedAt:    time.Now().Format(time.RFC3339),
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	_, err = config.DB.Collection("custom_challenges").InsertOne(ctx, challenge)
+	customChallenges := config.DB.Collection("custom_challenges")
+	inserted, err := customChallenges.InsertOne(ctx, challenge)
 	if err != nil {
+		discardPhoto()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database insert failed"})
 		return
 	}
 
-	// Get user data from database
-	var user models.User
-	err = config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
-		return
-	}
-
 	// ✅ Insert into gallery_posts using user data from database
 	gallery := models.GalleryPost{
 		UserID:       userID,
 		UserName:     user.Username,
 		UserAvatar:   user.AvatarURL,
 		ImageURL:     imageURL,
+		Guess:        true,
 		Choices:      choices,
 		CorrectIndex: correctIdx,
+		Answers:      answers,
+		Hint:         hint,
 		Prompt:       prompt,
 		Difficulty:   difficulty,
+		Rating:       guessRatingStart,
+		GroupID:      groupID,
 		Likes:        []string{},
 		CreatedAt:    time.Now(),
 	}
+	if answerMode == "text" {
+		gallery.AnswerMode = answerMode
+	}
+	fingerprint.apply(&gallery, rules.DuplicatePolicy)
 
 	_, err = config.DB.Collection("gallery_posts").InsertOne(ctx, gallery)
 	if err != nil {
 		fmt.Println("Gallery insert error:", err)
 		fmt.Println("Gallery data:", gallery)
+		if _, err := customChallenges.DeleteOne(ctx, bson.M{"_id": inserted.InsertedID}); err != nil {
+			fmt.Println("Failed to delete custom challenge:", err)
+		}
+		discardPhoto()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gallery insert failed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Custom challenge uploaded successfully"})
+	unlocked := checkAchievements(ctx, userID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Custom challenge uploaded successfully",
+		"achievements_unlocked": unlocked,
+		"duplicate_warning":     duplicateWarning(fingerprint, rules.DuplicatePolicy, userID),
+	})
 }
 
 // GetProgress
@@ -303,7 +507,7 @@ func GetProgress(c *gin.Context) {
 	count, err := userChallenges.CountDocuments(ctx, bson.M{
 		"email":  email,
 		"date":   date,
-		"status": "accepted",
+		"status": "completed",
 	})
 
 	if err != nil {
@@ -317,34 +521,50 @@ func GetProgress(c *gin.Context) {
 // SubmitChallenge
 // POST /challenge/submit
 func SubmitChallenge(c *gin.Context) {
-	// Extract user claims from JWT (set by middleware)
-	claims, exists := c.Get("claims")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	userID, email, ok := currentUser(c)
+	if !ok {
 		return
 	}
-	userClaims := claims.(jwt.MapClaims)
 
-	userIDHex, ok := userClaims["user_id"].(string)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user_id"})
+	// The submission must name one of the caller's open accepted challenges
+	challengeID, err := primitive.ObjectIDFromHex(c.PostForm("challenge_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing or invalid challenge_id"})
 		return
 	}
-	userID, err := primitive.ObjectIDFromHex(userIDHex)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	userChallenges := config.DB.Collection("user_challenges")
+
+	var challenge models.UserChallenge
+	err = userChallenges.FindOne(ctx, bson.M{"_id": challengeID, "email": email}).Decode(&challenge)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ObjectID"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge not found"})
+		return
+	}
+	if challenge.Status == "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already submitted a photo for this challenge"})
+		return
+	}
+	if challenge.Status != "accepted" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Challenge is " + challenge.Status + " and can no longer be submitted"})
 		return
 	}
 
-	username := userClaims["username"].(string)
-	avatar := userClaims["avatar"].(string)
-
-	// Get form values
-	task := c.PostForm("task")
-	difficulty := c.PostForm("difficulty")
+	rules := loadChallengeRules(ctx)
+	if !challenge.AcceptedAt.IsZero() && time.Since(challenge.AcceptedAt) > rules.SubmissionWindow() {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("The %d hour submission window for this challenge has closed", rules.SubmissionWindowHours)})
+		return
+	}
+	points := eventPoints(ctx, challenge.EventID, rules.Modes[challenge.Mode].Points)
 
-	if task == "" || difficulty == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing task or difficulty"})
+	// Get user data from database
+	var user models.User
+	err = config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
 		return
 	}
 
@@ -357,136 +577,163 @@ func SubmitChallenge(c *gin.Context) {
 	src, _ := header.Open()
 	defer src.Close()
 
-	// Upload to S3
-	imageURL, err := utils.UploadToS3(src, header)
+	photo, err := io.ReadAll(src)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to S3", "detail": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded photo"})
 		return
 	}
 
-	// Create gallery post
-	gallery := models.GalleryPost{
-		UserID:     userID,
-		UserName:   username,
-		UserAvatar: avatar,
-		ImageURL:   imageURL,
-		Task:       task,
-		Difficulty: difficulty,
-		Likes:      []string{},
-		CreatedAt:  time.Now(),
-	}
+	// The capture time decides whether the photo was taken for this challenge; older photos
+	// from the camera roll score less
+	meta, _ := utils.ReadPhotoMetadata(photo)
+	freshness := photoFreshness(meta, challenge)
+	points = freshnessPoints(rules, freshness, points)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// Geofenced challenges are checked against the photo's GPS and capture time. The EXIF
+	// and XMP metadata is stripped before upload so the exact position is never published.
+	var area *models.CoarseArea
+	if challenge.Geofence != nil {
+		var reason string
+		area, reason = verifyPhotoLocation(challenge, meta)
+		if area == nil {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": reason})
+			return
+		}
+		photo = utils.StripEXIF(photo)
+	}
 
-	_, err = config.DB.Collection("gallery_posts").InsertOne(ctx, gallery)
-	if err != nil {
-		fmt.Println("Gallery insert error:", err)
-		fmt.Println("Gallery data:", gallery)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gallery insert failed"})
+	palette, colorCheck := checkPhotoColors(challenge.Colors, photo)
+	if colorCheck == "rejected" {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"error":   "Photo doesn't show enough of the colour this challenge asks for",
+			"colors":  challenge.Colors,
+			"palette": palette,
+		})
 		return
 	}
+	points = colorCheckPoints(rules, colorCheck, points)
 
-	// Update user_challenges status to completed
-	filter := bson.M{
-		"email":  userClaims["email"].(string),
-		"prompt": task,
-		"mode":   difficulty,
-		"date":   time.Now().Format("2006-01-02"),
-	}
-	update := bson.M{
-		"$set": bson.M{
-			"status":    "completed",
-			"image_url": imageURL,
-		},
-	}
-	_, err = config.DB.Collection("user_challenges").UpdateOne(ctx, filter, update)
-	if err != nil {
-		fmt.Println("Failed to update challenge status:", err)
-		// Don't return error to user since the submission was successful
+	fingerprint := fingerprintPhoto(ctx, photo, rules.DuplicateMaxDistance)
+	if rejectDuplicate(c, fingerprint, rules.DuplicatePolicy, userID) {
+		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Challenge completed successfully",
-		"points":  100,
-	})
-}
-
-// GetGuessChallenge
-// GET /challenge/guess/:id
-func GetGuessChallenge(c *gin.Context) {
-	id := c.Param("id")
-	postID, err := primitive.ObjectIDFromHex(id)
+	imageURL, err := utils.UploadBytesToS3(photo, header)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge ID"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to S3", "detail": err.Error()})
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// The upload is only kept once a post uses it
+	discardPhoto := func() {
+		if err := utils.DeleteFromS3(imageURL); err != nil {
+			fmt.Println("Failed to delete unused upload:", err)
+		}
+	}
 
-	var post models.GalleryPost
-	err = config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postID}).Decode(&post)
+	// Claim the challenge before creating the post so concurrent submissions can't both succeed
+	res, err := userChallenges.UpdateOne(ctx,
+		bson.M{"_id": challengeID, "status": "accepted"},
+		bson.M{"$set": bson.M{
+			"status":    "completed",
+			"image_url": imageURL,
+			"closed_at": time.Now(),
+		}},
+	)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge not found"})
+		discardPhoto()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update challenge status"})
 		return
 	}
-
-	// Return challenge data
-	c.JSON(http.StatusOK, gin.H{
-		"id":            post.ID.Hex(),
-		"image_url":     post.ImageURL,
-		"prompt":        post.Prompt,
-		"choices":       post.Choices,
-		"correct_index": post.CorrectIndex,
-		"difficulty":    post.Difficulty,
-		"points":        100,
-		"created_at":    post.CreatedAt.Format(time.RFC3339),
-		"author":        post.UserName,
-	})
-}
-
-// SubmitGuessChallenge
-// POST /challenge/guess/submit
-func SubmitGuessChallenge(c *gin.Context) {
-	// Extract user claims from JWT
-	claims, exists := c.Get("claims")
-	if !exists {
-		fmt.Println("No JWT claims found")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	if res.ModifiedCount == 0 {
+		discardPhoto()
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already submitted a photo for this challenge"})
 		return
 	}
-	userClaims := claims.(jwt.MapClaims)
-	fmt.Printf("User claims: %+v\n", userClaims)
 
-	// Parse request
-	var req struct {
-		ChallengeID   string `json:"challenge_id"`
-		SelectedIndex int    `json:"selected_index"`
-		Email         string `json:"email"`
-	}
-	if err := c.BindJSON(&req); err != nil {
-		fmt.Printf("Error binding JSON: %v\n", err)
-		fmt.Printf("Request body: %v\n", c.Request.Body)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	// Create gallery post
+	gallery := models.GalleryPost{
+		UserID:      userID,
+		UserName:    user.Username,
+		UserAvatar:  user.AvatarURL,
+		ImageURL:    imageURL,
+		Task:        challenge.Prompt,
+		Difficulty:  challenge.Mode,
+		ChallengeID: &challengeID,
+		EventID:     challenge.EventID,
+		DuelID:      challenge.DuelID,
+		GroupID:     challenge.GroupID,
+		Area:        area,
+		Palette:     palette,
+		ColorCheck:  colorCheck,
+		Freshness:   freshness,
+		Points:      points,
+		Likes:       []string{},
+		CreatedAt:   time.Now(),
+	}
+	fingerprint.apply(&gallery, rules.DuplicatePolicy)
+	if freshness == "stale" {
+		gallery.Flags = append(gallery.Flags, "stale_photo")
+	}
+	if needsReview(rules, gallery) {
+		gallery.ReviewStatus = "pending"
+	}
+
+	inserted, err := config.DB.Collection("gallery_posts").InsertOne(ctx, gallery)
+	if err != nil {
+		fmt.Println("Gallery insert error:", err)
+		fmt.Println("Gallery data:", gallery)
+		// Reopen the challenge so the player can try again
+		_, _ = userChallenges.UpdateOne(ctx,
+			bson.M{"_id": challengeID},
+			bson.M{
+				"$set":   bson.M{"status": "accepted"},
+				"$unset": bson.M{"image_url": "", "closed_at": ""},
+			},
+		)
+		discardPhoto()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gallery insert failed"})
 		return
 	}
 
-	fmt.Printf("Request data: %+v\n", req)
+	gallery.ID = inserted.InsertedID.(primitive.ObjectID)
 
-	// Validate email matches JWT
-	if req.Email != userClaims["email"].(string) {
-		fmt.Printf("Email mismatch - Request: %s, JWT: %s\n", req.Email, userClaims["email"])
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Email mismatch"})
+	// Held posts stay out of the gallery and earn nothing until a moderator approves them
+	if gallery.ReviewStatus == "pending" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":           "Challenge submitted and waiting for review",
+			"challenge_id":      challengeID.Hex(),
+			"post_id":           gallery.ID,
+			"review_status":     gallery.ReviewStatus,
+			"points":            0,
+			"pending_points":    points,
+			"freshness":         freshness,
+			"duplicate_warning": duplicateWarning(fingerprint, rules.DuplicatePolicy, userID),
+		})
 		return
 	}
 
-	// Get challenge from database
-	postID, err := primitive.ObjectIDFromHex(req.ChallengeID)
+	unlocked := publishSubmission(ctx, gallery, challenge)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Challenge completed successfully",
+		"challenge_id":          challengeID.Hex(),
+		"post_id":               inserted.InsertedID,
+		"points":                points,
+		"freshness":             freshness,
+		"color_check":           colorCheck,
+		"achievements_unlocked": unlocked,
+		"duplicate_warning":     duplicateWarning(fingerprint, rules.DuplicatePolicy, userID),
+	})
+}
+
+// GetGuessChallenge
+// GET /challenge/guess/:id
+func GetGuessChallenge(c *gin.Context) {
+	id := c.Param("id")
+	postID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		fmt.Printf("Error converting challenge ID: %v\n", err)
-		fmt.Printf("Challenge ID received: %s\n", req.ChallengeID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge ID format"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge ID"})
 		return
 	}
 
@@ -496,98 +743,19 @@ func SubmitGuessChallenge(c *gin.Context) {
 	var post models.GalleryPost
 	err = config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postID}).Decode(&post)
 	if err != nil {
-		fmt.Printf("Error finding post: %v\n", err)
-		fmt.Printf("Looking for post ID: %s\n", postID.Hex())
 		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge not found"})
 		return
 	}
-
-	fmt.Printf("Found post: %+v\n", post)
-
-	// Check if user has already submitted an answer
-	userID, err := primitive.ObjectIDFromHex(userClaims["user_id"].(string))
-	if err != nil {
-		fmt.Printf("Error converting user ID: %v\n", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
-		return
-	}
-
-	count, err := config.DB.Collection("user_answers").CountDocuments(ctx, bson.M{
-		"user_id": userID,
-		"post_id": postID,
-	})
-	if err != nil {
-		fmt.Printf("Error checking existing answers: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing answers"})
+	if !checkPostVisible(ctx, c, post) {
 		return
 	}
-	if count > 0 {
-		fmt.Printf("User %s has already answered challenge %s\n", userID.Hex(), postID.Hex())
-		c.JSON(http.StatusConflict, gin.H{"error": "You have already submitted an answer for this challenge"})
-		return
-	}
-
-	// Validate selected index
-	if req.SelectedIndex < 0 || req.SelectedIndex >= len(post.Choices) {
-		fmt.Printf("Invalid answer index: %d (choices length: %d)\n", req.SelectedIndex, len(post.Choices))
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answer index"})
-		return
-	}
-
-	// Check answer
-	isCorrect := req.SelectedIndex == post.CorrectIndex
-	fmt.Printf("Answer check - Selected: %d, Correct: %d, IsCorrect: %v\n",
-		req.SelectedIndex, post.CorrectIndex, isCorrect)
-
-	// Save answer attempt
-	answer := models.UserAnswer{
-		UserID:     userID,
-		PostID:     postID,
-		Answer:     post.Choices[req.SelectedIndex],
-		IsCorrect:  isCorrect,
-		AnsweredAt: time.Now().Unix(),
-	}
+	viewerID, _ := primitive.ObjectIDFromHex(c.GetString("user_id"))
 
-	_, err = config.DB.Collection("user_answers").InsertOne(ctx, answer)
-	if err != nil {
-		fmt.Printf("Error saving answer: %v\n", err)
-		fmt.Printf("Attempted to save answer: %+v\n", answer)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save answer"})
-		return
-	}
-
-	points := 0
-	// Award points if correct based on difficulty
-	if isCorrect {
-		// Set points to 100 for all difficulty levels
-		points = 100
-
-		// Update user's total score
-		_, err = config.DB.Collection("users").UpdateOne(ctx,
-			bson.M{"_id": userID},
-			bson.M{"$inc": bson.M{"total_score": points}},
-		)
-		if err != nil {
-			fmt.Printf("Failed to update user score: %v\n", err)
-			// Don't return error to user since the answer was saved successfully
-		} else {
-			fmt.Printf("Updated user %s score by %d points\n", userID.Hex(), points)
-		}
-	}
-
-	// Get message based on correctness
-	message := "Incorrect answer. Try again!"
-	if isCorrect {
-		message = fmt.Sprintf("Correct answer! You earned %d points!", points)
-	}
+	c.JSON(http.StatusOK, guessChallengeResponse(ctx, post, viewerID))
+}
 
-	response := gin.H{
-		"is_correct":     isCorrect,
-		"points":         points,
-		"message":        message,
-		"answer":         post.Choices[req.SelectedIndex],
-		"correct_answer": post.Choices[post.CorrectIndex],
-	}
-	fmt.Printf("Sending response: %+v\n", response)
-	c.JSON(http.StatusOK, response)
+// SubmitGuessChallenge answers a guess post from the challenge screen
+// POST /challenge/guess/submit
+func SubmitGuessChallenge(c *gin.Context) {
+	submitGuess(c)
 }
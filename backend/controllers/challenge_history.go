@@ -0,0 +1,203 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// dailySlotStatuses are the user_challenges statuses that use up one of the day's slots.
+// A skip gives the slot back; abandoning or letting a challenge expire does not.
+var dailySlotStatuses = []string{"accepted", "completed", "abandoned", "expired"}
+
+// skipGracePeriod is how long after accepting a challenge it can still be skipped
+// (freeing the slot) instead of abandoned.
+const skipGracePeriod = 15 * time.Minute
+
+// expireStaleChallenges closes the user's accepted challenges whose submission window has
+// passed. Records from before accepted_at was stored expire at the end of their day.
+func expireStaleChallenges(ctx context.Context, email string) {
+	window := loadChallengeRules(ctx).SubmissionWindow()
+	_, err := config.DB.Collection("user_challenges").UpdateMany(ctx,
+		bson.M{
+			"email":  email,
+			"status": "accepted",
+			"$or": []bson.M{
+				{"accepted_at": bson.M{"$lt": time.Now().Add(-window)}},
+				{
+					"accepted_at": bson.M{"$exists": false},
+					"date":        bson.M{"$lt": time.Now().Format("2006-01-02")},
+				},
+			},
+		},
+		bson.M{"$set": bson.M{"status": "expired", "closed_at": time.Now()}},
+	)
+	if err != nil {
+		fmt.Println("Failed to expire stale challenges:", err)
+	}
+}
+
+// loadOpenChallenge loads the caller's open challenge named in the request body.
+func loadOpenChallenge(ctx context.Context, c *gin.Context) (models.UserChallenge, bool) {
+	var challenge models.UserChallenge
+
+	_, email, ok := currentUser(c)
+	if !ok {
+		return challenge, false
+	}
+
+	var req struct {
+		ChallengeID string `json:"challenge_id"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return challenge, false
+	}
+	challengeID, err := primitive.ObjectIDFromHex(req.ChallengeID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge_id"})
+		return challenge, false
+	}
+
+	expireStaleChallenges(ctx, email)
+
+	err = config.DB.Collection("user_challenges").FindOne(ctx, bson.M{
+		"_id":   challengeID,
+		"email": email,
+	}).Decode(&challenge)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Challenge not found"})
+		return challenge, false
+	}
+	if challenge.Status != "accepted" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Challenge is already " + challenge.Status})
+		return challenge, false
+	}
+	return challenge, true
+}
+
+// setChallengeStatus moves an accepted challenge to its final status. The status guard in the
+// filter makes a concurrent submit or skip lose cleanly instead of overwriting each other.
+func setChallengeStatus(ctx context.Context, id primitive.ObjectID, status string) (bool, error) {
+	res, err := config.DB.Collection("user_challenges").UpdateOne(ctx,
+		bson.M{"_id": id, "status": "accepted"},
+		bson.M{"$set": bson.M{"status": status, "closed_at": time.Now()}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// SkipChallenge
+// POST /challenge/skip
+func SkipChallenge(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	challenge, ok := loadOpenChallenge(ctx, c)
+	if !ok {
+		return
+	}
+
+	if challenge.AcceptedAt.IsZero() || time.Since(challenge.AcceptedAt) > skipGracePeriod {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": fmt.Sprintf("Challenges can only be skipped within %d minutes of accepting; abandon it instead", int(skipGracePeriod.Minutes())),
+		})
+		return
+	}
+
+	updated, err := setChallengeStatus(ctx, challenge.ID, "skipped")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to skip challenge"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusConflict, gin.H{"error": "Challenge is no longer open"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Challenge skipped",
+		"slot_freed": true,
+	})
+}
+
+// AbandonChallenge
+// POST /challenge/abandon
+func AbandonChallenge(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	challenge, ok := loadOpenChallenge(ctx, c)
+	if !ok {
+		return
+	}
+
+	updated, err := setChallengeStatus(ctx, challenge.ID, "abandoned")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to abandon challenge"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusConflict, gin.H{"error": "Challenge is no longer open"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "Challenge abandoned",
+		"slot_freed": false,
+	})
+}
+
+// GetChallengeHistory lists the caller's past and open challenges, newest first
+// GET /challenge/history?status=completed&limit=50
+func GetChallengeHistory(c *gin.Context) {
+	_, email, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 200 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 200"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	expireStaleChallenges(ctx, email)
+
+	filter := bson.M{"email": email}
+	if status := c.Query("status"); status != "" {
+		filter["status"] = status
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "date", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(limit))
+	cursor, err := config.DB.Collection("user_challenges").Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch challenge history"})
+		return
+	}
+
+	history := []models.UserChallenge{}
+	if err := cursor.All(ctx, &history); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse challenge history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, history)
+}
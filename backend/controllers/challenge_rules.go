@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+	"photoquest/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const challengeRulesID = "challenge_rules"
+
+// defaultChallengeRules are used until an admin saves rules of their own.
+func defaultChallengeRules() models.ChallengeRules {
+	return models.ChallengeRules{
+		DailyLimit: 5,
+		Modes: map[string]models.ModeRules{
+			"easy":   {DailyLimit: 5, Points: 100},
+			"medium": {DailyLimit: 5, Points: 100},
+			"hard":   {DailyLimit: 5, Points: 100},
+		},
+		GuessPoints:               100,
+		GuessTimeLimitSeconds:     30,
+		GuessSlowestPointsPercent: 50,
+		GuessHintCostPercent:      50,
+		GuessAuthorPoints:         10,
+		GuessAuthorMinAnswers:     5,
+		GuessAuthorPostCap:        200,
+		GuessAuthorDailyCap:       100,
+		RerollsPerDay:             10,
+		SubmissionWindowHours:     24,
+		DuelWinPoints:             150,
+		DuelVotingHours:           24,
+		TeamBonusPointsPerGoal:    50,
+		ReviewMode:                "off",
+		DuplicatePolicy:           "flag",
+		DuplicateMaxDistance:      5,
+		StalePhotoPointsPercent:   50,
+		UndatedPhotoPointsPercent: 100,
+		ColorReviewPointsPercent:  50,
+		ReportHideThreshold:       3,
+		DeletedPostPoints:         "revoke",
+	}
+}
+
+// loadChallengeRules returns the admin-edited rules, or the defaults when none are stored.
+// Settings added since the rules were saved keep their defaults.
+func loadChallengeRules(ctx context.Context) models.ChallengeRules {
+	rules := defaultChallengeRules()
+	// The decoder merges into a filled map, which would bring back modes an admin removed
+	rules.Modes = nil
+	err := config.DB.Collection("settings").FindOne(ctx, bson.M{"_id": challengeRulesID}).Decode(&rules)
+	if err != nil {
+		return defaultChallengeRules()
+	}
+	if len(rules.Modes) == 0 {
+		rules.Modes = defaultChallengeRules().Modes
+	}
+	return rules
+}
+
+// GetChallengeRules
+// GET /challenge/rules
+func GetChallengeRules(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	c.JSON(http.StatusOK, loadChallengeRules(ctx))
+}
+
+// UpdateChallengeRules replaces the stored rules
+// PUT /admin/challenge-rules
+func UpdateChallengeRules(c *gin.Context) {
+	var req models.ChallengeRules
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	if req.DailyLimit < 1 || len(req.Modes) == 0 || req.GuessPoints < 0 || req.RerollsPerDay < 0 || req.SubmissionWindowHours < 1 ||
+		req.DuelWinPoints < 0 || req.DuelVotingHours < 1 || req.ReportHideThreshold < 0 || req.TeamBonusPointsPerGoal < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "daily_limit, submission_window_hours and duel_voting_hours must be at least 1, modes must not be empty and nothing may be negative"})
+		return
+	}
+	if req.GuessTimeLimitSeconds < 0 || req.GuessSlowestPointsPercent < 0 || req.GuessSlowestPointsPercent > 100 ||
+		req.GuessHintCostPercent < 0 || req.GuessHintCostPercent > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "guess_time_limit_seconds must not be negative and guess_slowest_points_percent and guess_hint_cost_percent must be between 0 and 100"})
+		return
+	}
+	if req.GuessAuthorPoints < 0 || req.GuessAuthorMinAnswers < 1 || req.GuessAuthorPostCap < 0 || req.GuessAuthorDailyCap < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "guess_author_min_answers must be at least 1 and the other guess_author settings must not be negative"})
+		return
+	}
+	if req.ReviewMode != "off" && req.ReviewMode != "flagged" && req.ReviewMode != "all" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "review_mode must be off, flagged or all"})
+		return
+	}
+	if req.DeletedPostPoints != "revoke" && req.DeletedPostPoints != "keep" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "deleted_post_points must be revoke or keep"})
+		return
+	}
+	if req.DuplicatePolicy != "reject" && req.DuplicatePolicy != "flag" && req.DuplicatePolicy != "allow" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duplicate_policy must be reject, flag or allow"})
+		return
+	}
+	if req.DuplicateMaxDistance < 0 || req.DuplicateMaxDistance >= utils.PerceptualHashBands {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("duplicate_max_distance must be between 0 and %d", utils.PerceptualHashBands-1)})
+		return
+	}
+	if req.StalePhotoPointsPercent < 0 || req.StalePhotoPointsPercent > 100 ||
+		req.UndatedPhotoPointsPercent < 0 || req.UndatedPhotoPointsPercent > 100 ||
+		req.ColorReviewPointsPercent < 0 || req.ColorReviewPointsPercent > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stale_photo_points_percent, undated_photo_points_percent and color_review_points_percent must be between 0 and 100"})
+		return
+	}
+	for mode, modeRules := range req.Modes {
+		if modeRules.DailyLimit < 0 || modeRules.Points < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Invalid rules for mode %s", mode)})
+			return
+		}
+	}
+	req.UpdatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := config.DB.Collection("settings").ReplaceOne(ctx,
+		bson.M{"_id": challengeRulesID},
+		req,
+		options.Replace().SetUpsert(true),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save challenge rules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Challenge rules updated", "rules": req})
+}
+
+// poolPrompt finds a prompt in the regular pool so the checks it declares can be copied onto
+// an accepted challenge. It returns nil when the prompt is not in the pool.
+func poolPrompt(ctx context.Context, prompt, mode string) *models.Challenge {
+	var challenge models.Challenge
+	err := config.DB.Collection("challenges").FindOne(ctx, bson.M{"prompt": prompt, "mode": mode}).Decode(&challenge)
+	if err != nil {
+		return nil
+	}
+	return &challenge
+}
+
+// CreateChallengePrompt adds a prompt to the regular pool, optionally tied to a place
+// POST /admin/challenges
+func CreateChallengePrompt(c *gin.Context) {
+	var req models.Challenge
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	req.Prompt = strings.TrimSpace(req.Prompt)
+	if req.Prompt == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing prompt"})
+		return
+	}
+	if msg := validateGeofence(req.Geofence); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	colors, msg := normalizeColors(req.Colors)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	req.Colors = colors
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, ok := loadChallengeRules(ctx).Modes[req.Mode]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown mode"})
+		return
+	}
+
+	if _, err := config.DB.Collection("challenges").InsertOne(ctx, req); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Challenge created", "challenge": req})
+}
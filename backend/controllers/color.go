@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"fmt"
+
+	"photoquest/models"
+	"photoquest/utils"
+)
+
+// A colour prompt is verified when its colours cover at least colorVerifiedShare of the photo,
+// goes to review above colorReviewShare, and is rejected below that.
+const (
+	colorVerifiedShare = 0.10
+	colorReviewShare   = 0.02
+	// Colours smaller than this are left out of the stored palette
+	paletteMinShare = 0.03
+)
+
+// normalizeColors turns colour names or hex values into bucket names, dropping duplicates.
+// It returns a message describing the first invalid colour, or "".
+func normalizeColors(colors []string) ([]string, string) {
+	var names []string
+	seen := map[string]bool{}
+	for _, color := range colors {
+		name, ok := utils.ParseColorName(color)
+		if !ok {
+			return nil, fmt.Sprintf("Unknown colour %q; use a name like red or a hex value like #ff0000", color)
+		}
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	return names, ""
+}
+
+// colorCheckPoints scales a submission's points down when its colours could only be partly
+// confirmed, as freshnessPoints does for old photos.
+func colorCheckPoints(rules models.ChallengeRules, colorCheck string, points int) int {
+	if colorCheck == "needs_review" {
+		return points * rules.ColorReviewPointsPercent / 100
+	}
+	return points
+}
+
+// checkPhotoColors computes the photo's palette and, for colour prompts, how well it matches.
+// The check is "" when the prompt has no colours, otherwise verified, needs_review or rejected.
+// Photos we can't decode go to review rather than being rejected.
+func checkPhotoColors(targets []string, photo []byte) ([]models.PaletteColor, string) {
+	colors, err := utils.DominantColors(photo, 0)
+	if err != nil {
+		if len(targets) == 0 {
+			return nil, ""
+		}
+		return nil, "needs_review"
+	}
+
+	targetShare := 0.0
+	var palette []models.PaletteColor
+	for _, color := range colors {
+		for _, target := range targets {
+			if color.Name == target {
+				targetShare += color.Share
+			}
+		}
+		if color.Share >= paletteMinShare {
+			palette = append(palette, color)
+		}
+	}
+
+	switch {
+	case len(targets) == 0:
+		return palette, ""
+	case targetShare >= colorVerifiedShare:
+		return palette, "verified"
+	case targetShare >= colorReviewShare:
+		return palette, "needs_review"
+	default:
+		return palette, "rejected"
+	}
+}
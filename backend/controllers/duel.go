@@ -0,0 +1,543 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recordDuelResult updates both players' duel records and pays the winner. A nil winner is a draw.
+func recordDuelResult(ctx context.Context, duel models.Duel, winner *primitive.ObjectID) {
+	users := config.DB.Collection("users")
+	if winner == nil {
+		_, err := users.UpdateMany(ctx,
+			bson.M{"_id": bson.M{"$in": []primitive.ObjectID{duel.ChallengerID, duel.OpponentID}}},
+			bson.M{"$inc": bson.M{"duel_record.draws": 1}},
+		)
+		if err != nil {
+			fmt.Println("Failed to record duel draw:", err)
+		}
+		return
+	}
+
+	loser := duel.ChallengerID
+	if *winner == duel.ChallengerID {
+		loser = duel.OpponentID
+	}
+	if _, err := users.UpdateByID(ctx, *winner, bson.M{"$inc": bson.M{"duel_record.wins": 1}}); err != nil {
+		fmt.Println("Failed to record duel win:", err)
+	}
+	if _, err := users.UpdateByID(ctx, loser, bson.M{"$inc": bson.M{"duel_record.losses": 1}}); err != nil {
+		fmt.Println("Failed to record duel loss:", err)
+	}
+	if err := awardPoints(ctx, *winner, loadChallengeRules(ctx).DuelWinPoints); err != nil {
+		fmt.Println("Failed to update user score:", err)
+	}
+}
+
+// settleDuel closes a duel whose submission or voting deadline has passed. Each transition is
+// guarded on the current status so the result is only recorded once.
+func settleDuel(ctx context.Context, duel *models.Duel) {
+	now := time.Now()
+	duels := config.DB.Collection("duels")
+
+	var winner *primitive.ObjectID
+	update := bson.M{}
+	switch {
+	case duel.Status == "active" && now.After(duel.SubmitDeadline):
+		// Whoever submitted wins by forfeit; with no photos at all the duel just expires
+		switch {
+		case duel.ChallengerPostID != nil:
+			winner = &duel.ChallengerID
+		case duel.OpponentPostID != nil:
+			winner = &duel.OpponentID
+		}
+		update["status"] = "finished"
+		if winner == nil {
+			update["status"] = "expired"
+		}
+	case duel.Status == "voting" && now.After(duel.VotingEndsAt):
+		switch {
+		case len(duel.ChallengerVotes) > len(duel.OpponentVotes):
+			winner = &duel.ChallengerID
+		case len(duel.OpponentVotes) > len(duel.ChallengerVotes):
+			winner = &duel.OpponentID
+		}
+		update["status"] = "finished"
+	default:
+		return
+	}
+	if winner != nil {
+		update["winner_id"] = *winner
+	}
+
+	res, err := duels.UpdateOne(ctx, bson.M{"_id": duel.ID, "status": duel.Status}, bson.M{"$set": update})
+	if err != nil {
+		fmt.Println("Failed to settle duel:", err)
+		return
+	}
+	if res.ModifiedCount == 0 {
+		return
+	}
+	if update["status"] == "finished" {
+		recordDuelResult(ctx, *duel, winner)
+	}
+	duel.Status = update["status"].(string)
+	duel.WinnerID = winner
+}
+
+// findDuels loads duels matching filter, settling any that are overdue.
+func findDuels(ctx context.Context, filter bson.M) ([]models.Duel, error) {
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(100)
+	cursor, err := config.DB.Collection("duels").Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	duels := []models.Duel{}
+	if err := cursor.All(ctx, &duels); err != nil {
+		return nil, err
+	}
+	for i := range duels {
+		settleDuel(ctx, &duels[i])
+	}
+	return duels, nil
+}
+
+// attachDuelPost links a submission made through /challenge/submit to its duel and opens
+// voting once both photos are in.
+func attachDuelPost(ctx context.Context, duelID, userID, postID primitive.ObjectID) {
+	duels := config.DB.Collection("duels")
+
+	var duel models.Duel
+	if err := duels.FindOne(ctx, bson.M{"_id": duelID}).Decode(&duel); err != nil {
+		fmt.Println("Failed to load duel:", err)
+		return
+	}
+	field := "opponent_post_id"
+	if userID == duel.ChallengerID {
+		field = "challenger_post_id"
+	}
+	_, err := duels.UpdateOne(ctx,
+		bson.M{"_id": duelID, "status": "active", field: bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{field: postID}},
+	)
+	if err != nil {
+		fmt.Println("Failed to attach duel post:", err)
+		return
+	}
+
+	votingWindow := time.Duration(loadChallengeRules(ctx).DuelVotingHours) * time.Hour
+	_, err = duels.UpdateOne(ctx,
+		bson.M{
+			"_id":                duelID,
+			"status":             "active",
+			"challenger_post_id": bson.M{"$exists": true},
+			"opponent_post_id":   bson.M{"$exists": true},
+		},
+		bson.M{"$set": bson.M{"status": "voting", "voting_ends_at": time.Now().Add(votingWindow)}},
+	)
+	if err != nil {
+		fmt.Println("Failed to open duel voting:", err)
+	}
+}
+
+// duelResponse adds the vote counts and photos to a duel. Votes stay hidden while voting is open
+// so they don't sway the remaining voters.
+func duelResponse(ctx context.Context, duel models.Duel, email string) gin.H {
+	response := gin.H{"duel": duel}
+
+	var postIDs []primitive.ObjectID
+	for _, id := range []*primitive.ObjectID{duel.ChallengerPostID, duel.OpponentPostID} {
+		if id != nil {
+			postIDs = append(postIDs, *id)
+		}
+	}
+	if len(postIDs) > 0 {
+		cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"_id": bson.M{"$in": postIDs}})
+		if err == nil {
+			var posts []models.GalleryPost
+			if cursor.All(ctx, &posts) == nil {
+				images := gin.H{}
+				for _, post := range posts {
+					images[post.ID.Hex()] = post.ImageURL
+				}
+				response["images"] = images
+			}
+		}
+	}
+
+	votedFor := ""
+	for _, voter := range duel.ChallengerVotes {
+		if voter == email {
+			votedFor = "challenger"
+		}
+	}
+	for _, voter := range duel.OpponentVotes {
+		if voter == email {
+			votedFor = "opponent"
+		}
+	}
+	response["voted_for"] = votedFor
+
+	if duel.Status == "finished" {
+		response["challenger_votes"] = len(duel.ChallengerVotes)
+		response["opponent_votes"] = len(duel.OpponentVotes)
+	}
+	return response
+}
+
+// CreateDuel challenges another player on a random prompt of the chosen mode
+// POST /duels
+func CreateDuel(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Opponent string `json:"opponent"` // username
+		Mode     string `json:"mode"`
+	}
+	if err := c.BindJSON(&req); err != nil || req.Opponent == "" || req.Mode == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing opponent or mode"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, ok := loadChallengeRules(ctx).Modes[req.Mode]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown mode"})
+		return
+	}
+
+	users := config.DB.Collection("users")
+	var challenger, opponent models.User
+	if err := users.FindOne(ctx, bson.M{"_id": userID}).Decode(&challenger); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		return
+	}
+	if err := users.FindOne(ctx, bson.M{"username": req.Opponent}).Decode(&opponent); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Opponent not found"})
+		return
+	}
+	if opponent.ID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't duel yourself"})
+		return
+	}
+
+	// Both players get the same prompt, picked now. Geofenced prompts are left out since the
+	// players may be nowhere near each other.
+	cursor, err := config.DB.Collection("challenges").Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"mode": req.Mode, "geofence": bson.M{"$exists": false}}}},
+		{{Key: "$sample", Value: bson.M{"size": 1}}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch challenges"})
+		return
+	}
+	var prompts []models.Challenge
+	if err := cursor.All(ctx, &prompts); err != nil || len(prompts) == 0 {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "No challenges available"})
+		return
+	}
+
+	duel := models.Duel{
+		ChallengerID:    userID,
+		ChallengerName:  challenger.Username,
+		OpponentID:      opponent.ID,
+		OpponentName:    opponent.Username,
+		Prompt:          prompts[0].Prompt,
+		Mode:            req.Mode,
+		Status:          "pending",
+		CreatedAt:       time.Now(),
+		ChallengerVotes: []string{},
+		OpponentVotes:   []string{},
+	}
+	res, err := config.DB.Collection("duels").InsertOne(ctx, duel)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create duel"})
+		return
+	}
+	duel.ID = res.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Duel sent to " + opponent.Username, "duel": duel})
+}
+
+// AcceptDuel gives both players an accepted challenge with the duel's prompt to submit
+// through /challenge/submit
+// POST /duels/:id/accept
+func AcceptDuel(c *gin.Context) {
+	respondToDuel(c, true)
+}
+
+// DeclineDuel
+// POST /duels/:id/decline
+func DeclineDuel(c *gin.Context) {
+	respondToDuel(c, false)
+}
+
+// respondToDuel answers a pending duel addressed to the caller.
+func respondToDuel(c *gin.Context, accept bool) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	duelID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duel ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rules := loadChallengeRules(ctx)
+	now := time.Now()
+	update := bson.M{"status": "declined"}
+	if accept {
+		update = bson.M{"status": "active", "submit_deadline": now.Add(rules.SubmissionWindow())}
+	}
+
+	var duel models.Duel
+	err = config.DB.Collection("duels").FindOneAndUpdate(ctx,
+		bson.M{"_id": duelID, "opponent_id": userID, "status": "pending"},
+		bson.M{"$set": update},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&duel)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending duel to respond to"})
+		return
+	}
+	if !accept {
+		c.JSON(http.StatusOK, gin.H{"message": "Duel declined"})
+		return
+	}
+
+	cursor, err := config.DB.Collection("users").Find(ctx,
+		bson.M{"_id": bson.M{"$in": []primitive.ObjectID{duel.ChallengerID, duel.OpponentID}}},
+		options.Find().SetProjection(bson.M{"email": 1}),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		return
+	}
+	var players []models.User
+	if err := cursor.All(ctx, &players); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		return
+	}
+
+	var challengeID interface{}
+	for _, player := range players {
+		res, err := config.DB.Collection("user_challenges").InsertOne(ctx, models.UserChallenge{
+			Email:      strings.ToLower(player.Email),
+			Date:       now.Format("2006-01-02"),
+			Prompt:     duel.Prompt,
+			Mode:       duel.Mode,
+			Status:     "accepted",
+			AcceptedAt: now,
+			DuelID:     &duel.ID,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save challenge"})
+			return
+		}
+		if player.ID == userID {
+			challengeID = res.InsertedID
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Duel accepted",
+		"challenge_id":  challengeID,
+		"submit_before": duel.SubmitDeadline,
+	})
+}
+
+// GetMyDuels lists duels the caller is part of
+// GET /duels
+func GetMyDuels(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	duels, err := findDuels(ctx, bson.M{"$or": []bson.M{
+		{"challenger_id": userID},
+		{"opponent_id": userID},
+	}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duels"})
+		return
+	}
+
+	c.JSON(http.StatusOK, duels)
+}
+
+// GetVotingDuels lists duels the caller can still vote on
+// GET /duels/voting
+func GetVotingDuels(c *gin.Context) {
+	userID, email, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	duels, err := findDuels(ctx, bson.M{
+		"status":        "voting",
+		"challenger_id": bson.M{"$ne": userID},
+		"opponent_id":   bson.M{"$ne": userID},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duels"})
+		return
+	}
+
+	result := []gin.H{}
+	for _, duel := range duels {
+		if duel.Status == "voting" {
+			result = append(result, duelResponse(ctx, duel, email))
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetDuel
+// GET /duels/:id
+func GetDuel(c *gin.Context) {
+	_, email, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	duelID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duel ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	duels, err := findDuels(ctx, bson.M{"_id": duelID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch duel"})
+		return
+	}
+	if len(duels) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Duel not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, duelResponse(ctx, duels[0], email))
+}
+
+// VoteDuel votes for one side of a duel. Voting for the same side again takes the vote back,
+// the same way ToggleLike works.
+// POST /duels/:id/vote
+func VoteDuel(c *gin.Context) {
+	userID, email, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	duelID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duel ID"})
+		return
+	}
+
+	var req struct {
+		PostID string `json:"post_id"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	postID, err := primitive.ObjectIDFromHex(req.PostID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post_id"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	duels, err := findDuels(ctx, bson.M{"_id": duelID})
+	if err != nil || len(duels) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Duel not found"})
+		return
+	}
+	duel := duels[0]
+	if duel.Status != "voting" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Voting is not open for this duel"})
+		return
+	}
+	if userID == duel.ChallengerID || userID == duel.OpponentID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't vote in your own duel"})
+		return
+	}
+
+	var side, otherSide string
+	var votes []string
+	switch {
+	case duel.ChallengerPostID != nil && *duel.ChallengerPostID == postID:
+		side, otherSide, votes = "challenger_votes", "opponent_votes", duel.ChallengerVotes
+	case duel.OpponentPostID != nil && *duel.OpponentPostID == postID:
+		side, otherSide, votes = "opponent_votes", "challenger_votes", duel.OpponentVotes
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Post is not part of this duel"})
+		return
+	}
+
+	voted := false
+	for _, voter := range votes {
+		if voter == email {
+			voted = true
+			break
+		}
+	}
+
+	update := bson.M{
+		"$addToSet": bson.M{side: email},
+		"$pull":     bson.M{otherSide: email},
+	}
+	if voted {
+		update = bson.M{"$pull": bson.M{side: email}}
+	}
+
+	res, err := config.DB.Collection("duels").UpdateOne(ctx,
+		bson.M{"_id": duelID, "status": "voting", "voting_ends_at": bson.M{"$gt": time.Now()}},
+		update,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Vote failed"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Voting has closed"})
+		return
+	}
+
+	status := "voted"
+	if voted {
+		status = "removed vote"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Successfully " + status})
+}
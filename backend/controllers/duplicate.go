@@ -0,0 +1,239 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+	"photoquest/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// photoFingerprint is an upload's perceptual hash and the closest earlier post it matches.
+type photoFingerprint struct {
+	Hash      string
+	Bands     []string
+	Duplicate *models.GalleryPost
+}
+
+// fingerprintPhoto hashes an upload and looks for a near-identical photo in gallery_posts.
+// Photos that can't be decoded get an empty fingerprint and are never treated as duplicates.
+func fingerprintPhoto(ctx context.Context, photo []byte, maxDistance int) photoFingerprint {
+	hash, err := utils.PerceptualHash(photo)
+	if err != nil {
+		return photoFingerprint{}
+	}
+	fp := photoFingerprint{Hash: utils.FormatHash(hash), Bands: utils.HashBands(hash)}
+	fp.Duplicate = findDuplicatePost(ctx, hash, fp.Bands, maxDistance)
+	return fp
+}
+
+// findDuplicatePost returns the closest post within maxDistance bits, or nil. Candidates share at
+// least one hash band, which every post within the distance limit is guaranteed to do.
+func findDuplicatePost(ctx context.Context, hash uint64, bands []string, maxDistance int) *models.GalleryPost {
+	findOptions := options.Find().SetProjection(bson.M{"_id": 1, "user_id": 1, "group_id": 1, "phash": 1})
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"phash_bands": bson.M{"$in": bands}}, findOptions)
+	if err != nil {
+		fmt.Println("Failed to look up duplicate photos:", err)
+		return nil
+	}
+	var candidates []models.GalleryPost
+	if err := cursor.All(ctx, &candidates); err != nil {
+		fmt.Println("Failed to parse duplicate candidates:", err)
+		return nil
+	}
+
+	var closest *models.GalleryPost
+	closestDistance := maxDistance + 1
+	for i, candidate := range candidates {
+		other, err := utils.ParseHash(candidate.PHash)
+		if err != nil {
+			continue
+		}
+		if distance := utils.HashDistance(hash, other); distance < closestDistance {
+			closest = &candidates[i]
+			closestDistance = distance
+		}
+	}
+	return closest
+}
+
+// reusedHuntPhoto reports whether the player already used a near-identical photo for a step
+// of any hunt run, so an expired run's photos can't be submitted again for more points.
+func reusedHuntPhoto(ctx context.Context, userID primitive.ObjectID, fp photoFingerprint, maxDistance int) (bool, error) {
+	if fp.Hash == "" {
+		return false, nil
+	}
+	hash, err := utils.ParseHash(fp.Hash)
+	if err != nil {
+		return false, nil
+	}
+	findOptions := options.Find().SetProjection(bson.M{"steps.phash": 1})
+	cursor, err := config.DB.Collection("user_hunts").Find(ctx,
+		bson.M{"user_id": userID, "steps.phash_bands": bson.M{"$in": fp.Bands}}, findOptions)
+	if err != nil {
+		return false, err
+	}
+	var runs []models.UserHunt
+	if err := cursor.All(ctx, &runs); err != nil {
+		return false, err
+	}
+	for _, run := range runs {
+		for _, step := range run.Steps {
+			other, err := utils.ParseHash(step.PHash)
+			if err == nil && utils.HashDistance(hash, other) <= maxDistance {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// apply stores the fingerprint on a new post and marks it according to the duplicate policy.
+func (fp photoFingerprint) apply(post *models.GalleryPost, policy string) {
+	post.PHash = fp.Hash
+	post.PHashBands = fp.Bands
+	if fp.Duplicate == nil {
+		return
+	}
+	post.DuplicateOf = &fp.Duplicate.ID
+	if policy == "flag" {
+		post.Flags = append(post.Flags, "duplicate")
+	}
+}
+
+// duplicateDetails describes the matched post to the uploader. Posts in private groups are
+// not linked, so the response can't reveal them.
+func (fp photoFingerprint) duplicateDetails(userID primitive.ObjectID) gin.H {
+	details := gin.H{"own_photo": fp.Duplicate.UserID == userID}
+	if fp.Duplicate.GroupID == nil {
+		details["duplicate_of"] = fp.Duplicate.ID.Hex()
+	}
+	return details
+}
+
+// rejectDuplicate writes the 409 response when the policy rejects re-used photos and returns
+// true; otherwise it does nothing and returns false.
+func rejectDuplicate(c *gin.Context, fp photoFingerprint, policy string, userID primitive.ObjectID) bool {
+	if fp.Duplicate == nil || policy != "reject" {
+		return false
+	}
+	response := fp.duplicateDetails(userID)
+	response["error"] = "This photo has already been posted to the gallery"
+	c.JSON(http.StatusConflict, response)
+	return true
+}
+
+// duplicateWarning is added to the success response when a re-used photo was let through.
+func duplicateWarning(fp photoFingerprint, policy string, userID primitive.ObjectID) gin.H {
+	if fp.Duplicate == nil {
+		return nil
+	}
+	warning := fp.duplicateDetails(userID)
+	warning["message"] = "This photo looks like one already in the gallery"
+	if policy == "flag" {
+		warning["message"] = "This photo looks like one already in the gallery and has been flagged for a moderator"
+	}
+	return warning
+}
+
+// GetFlaggedPosts lists posts that need a moderator's look
+// GET /moderation/posts/flagged
+func GetFlaggedPosts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"$or": bson.A{
+		bson.M{"flags.0": bson.M{"$exists": true}},
+		bson.M{"color_check": "needs_review"},
+	}}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch flagged posts"})
+		return
+	}
+
+	posts := []models.GalleryPost{}
+	if err := cursor.All(ctx, &posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse posts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+// BackfillPhotoHashes hashes existing posts so new uploads can be matched against them.
+// It handles a batch per call; repeat until remaining is 0.
+// POST /admin/posts/phash-backfill
+func BackfillPhotoHashes(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	posts := config.DB.Collection("gallery_posts")
+	missing := bson.M{"phash": bson.M{"$exists": false}, "phash_failed": bson.M{"$exists": false}}
+	findOptions := options.Find().SetLimit(100).SetProjection(bson.M{"_id": 1, "image_url": 1})
+	cursor, err := posts.Find(ctx, missing, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch posts"})
+		return
+	}
+	var batch []models.GalleryPost
+	if err := cursor.All(ctx, &batch); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse posts"})
+		return
+	}
+
+	client := &http.Client{Timeout: 15 * time.Second}
+	hashed, failed := 0, 0
+	for _, post := range batch {
+		postCtx, postCancel := context.WithTimeout(context.Background(), 20*time.Second)
+		update := bson.M{"$set": bson.M{"phash_failed": true}}
+		if hash, err := downloadAndHash(postCtx, client, post.ImageURL); err != nil {
+			fmt.Println("Failed to hash post", post.ID.Hex()+":", err)
+			failed++
+		} else {
+			update = bson.M{"$set": bson.M{"phash": utils.FormatHash(hash), "phash_bands": utils.HashBands(hash)}}
+			hashed++
+		}
+		if _, err := posts.UpdateByID(postCtx, post.ID, update); err != nil {
+			fmt.Println("Failed to save hash for post", post.ID.Hex()+":", err)
+		}
+		postCancel()
+	}
+
+	remaining, _ := posts.CountDocuments(ctx, missing)
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Photo hashes backfilled",
+		"hashed":    hashed,
+		"failed":    failed,
+		"remaining": remaining,
+	})
+}
+
+func downloadAndHash(ctx context.Context, client *http.Client, url string) (uint64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 32<<20))
+	if err != nil {
+		return 0, err
+	}
+	return utils.PerceptualHash(data)
+}
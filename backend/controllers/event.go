@@ -0,0 +1,295 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// activeEvent returns the running event that started most recently, or nil when none is running.
+func activeEvent(ctx context.Context) (*models.Event, error) {
+	now := time.Now()
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "start_at", Value: -1}})
+
+	var event models.Event
+	err := config.DB.Collection("events").FindOne(ctx, bson.M{
+		"start_at": bson.M{"$lte": now},
+		"end_at":   bson.M{"$gt": now},
+	}, findOptions).Decode(&event)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// eventPrompts returns the event's prompts for one mode.
+func eventPrompts(event *models.Event, mode string) []models.Challenge {
+	var prompts []models.Challenge
+	for _, prompt := range event.Prompts {
+		if prompt.Mode == mode {
+			prompts = append(prompts, prompt)
+		}
+	}
+	return prompts
+}
+
+// eventPoints applies the event bonus to a submission's points. The bonus only counts while
+// the event is still running.
+func eventPoints(ctx context.Context, eventID *primitive.ObjectID, points int) int {
+	if eventID == nil {
+		return points
+	}
+	var event models.Event
+	err := config.DB.Collection("events").FindOne(ctx, bson.M{"_id": *eventID}).Decode(&event)
+	if err != nil || time.Now().After(event.EndAt) {
+		return points
+	}
+	return int(math.Round(float64(points) * event.PointsMultiplier))
+}
+
+// bindEvent reads and validates an event from the request body.
+func bindEvent(c *gin.Context) (models.Event, bool) {
+	var req models.Event
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return req, false
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || req.StartAt.IsZero() || req.EndAt.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing name, start_at or end_at"})
+		return req, false
+	}
+	if !req.EndAt.After(req.StartAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_at must be after start_at"})
+		return req, false
+	}
+	if len(req.Prompts) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "An event needs at least one prompt"})
+		return req, false
+	}
+	for i, prompt := range req.Prompts {
+		if strings.TrimSpace(prompt.Prompt) == "" || prompt.Mode == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Every prompt needs text and a mode"})
+			return req, false
+		}
+		if msg := validateGeofence(prompt.Geofence); msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return req, false
+		}
+		colors, msg := normalizeColors(prompt.Colors)
+		if msg != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+			return req, false
+		}
+		req.Prompts[i].Colors = colors
+	}
+	if req.PointsMultiplier == 0 {
+		req.PointsMultiplier = 1
+	}
+	if req.PointsMultiplier < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "points_multiplier must be at least 1"})
+		return req, false
+	}
+	return req, true
+}
+
+// GetEvents lists events, optionally only the running or upcoming ones
+// GET /events?status=active|upcoming
+func GetEvents(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	filter := bson.M{}
+	switch c.Query("status") {
+	case "":
+	case "active":
+		filter = bson.M{"start_at": bson.M{"$lte": now}, "end_at": bson.M{"$gt": now}}
+	case "upcoming":
+		filter = bson.M{"start_at": bson.M{"$gt": now}}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or upcoming"})
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "start_at", Value: -1}})
+	cursor, err := config.DB.Collection("events").Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
+		return
+	}
+
+	events := []models.Event{}
+	if err := cursor.All(ctx, &events); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// GetEventLeaderboard ranks players by the points they earned from the event's challenges
+// GET /events/:id/leaderboard
+func GetEventLeaderboard(c *gin.Context) {
+	eventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var event models.Event
+	if err := config.DB.Collection("events").FindOne(ctx, bson.M{"_id": eventID}).Decode(&event); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: publishedPosts(bson.M{"event_id": eventID})}},
+		{{Key: "$sort", Value: bson.M{"created_at": -1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$user_id",
+			"user_name":   bson.M{"$first": "$user_name"},
+			"user_avatar": bson.M{"$first": "$user_avatar"},
+			"points":      bson.M{"$sum": "$points"},
+			"submissions": bson.M{"$sum": 1},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "points", Value: -1}, {Key: "submissions", Value: -1}}}},
+	}
+	cursor, err := config.DB.Collection("gallery_posts").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch event leaderboard"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		UserID      primitive.ObjectID `bson:"_id"`
+		UserName    string             `bson:"user_name"`
+		UserAvatar  string             `bson:"user_avatar"`
+		Points      int                `bson:"points"`
+		Submissions int                `bson:"submissions"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decode error"})
+		return
+	}
+
+	leaderboard := []gin.H{}
+	for i, row := range rows {
+		leaderboard = append(leaderboard, gin.H{
+			"rank":        i + 1,
+			"user_id":     row.UserID.Hex(),
+			"username":    row.UserName,
+			"avatar_url":  row.UserAvatar,
+			"points":      row.Points,
+			"submissions": row.Submissions,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"event":       event.Name,
+		"leaderboard": leaderboard,
+	})
+}
+
+// CreateEvent
+// POST /admin/events
+func CreateEvent(c *gin.Context) {
+	event, ok := bindEvent(c)
+	if !ok {
+		return
+	}
+	event.ID = primitive.NilObjectID
+	event.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := config.DB.Collection("events").InsertOne(ctx, event)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
+		return
+	}
+	event.ID = res.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Event created", "event": event})
+}
+
+// UpdateEvent
+// PUT /admin/events/:id
+func UpdateEvent(c *gin.Context) {
+	eventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	event, ok := bindEvent(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := config.DB.Collection("events").UpdateByID(ctx, eventID, bson.M{"$set": bson.M{
+		"name":              event.Name,
+		"description":       event.Description,
+		"start_at":          event.StartAt,
+		"end_at":            event.EndAt,
+		"prompts":           event.Prompts,
+		"points_multiplier": event.PointsMultiplier,
+	}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Event updated"})
+}
+
+// DeleteEvent removes a scheduled event. Posts already tagged with it keep their event_id.
+// DELETE /admin/events/:id
+func DeleteEvent(c *gin.Context) {
+	eventID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := config.DB.Collection("events").DeleteOne(ctx, bson.M{"_id": eventID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Event deleted"})
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"time"
+
+	"photoquest/models"
+	"photoquest/utils"
+)
+
+// Camera clocks drift, so capture times get a little slack. When the photo has no UTC
+// offset its time zone is unknown and the slack has to cover every zone.
+const (
+	captureClockSkew   = 10 * time.Minute
+	captureUnknownZone = 14 * time.Hour
+)
+
+// photoFreshness compares a photo's capture time with when the challenge was accepted:
+// fresh when it was taken after accepting, stale when taken before or dated in the future,
+// undated when the photo carries no capture time.
+func photoFreshness(meta utils.PhotoMetadata, challenge models.UserChallenge) string {
+	if meta.TakenAt.IsZero() {
+		return "undated"
+	}
+
+	// Challenges from before accepted_at was recorded only know their day
+	acceptedAt := challenge.AcceptedAt
+	if acceptedAt.IsZero() {
+		day, err := time.ParseInLocation("2006-01-02", challenge.Date, time.Local)
+		if err != nil {
+			return "undated"
+		}
+		acceptedAt = day
+	}
+	return freshnessSince(meta, acceptedAt)
+}
+
+// freshnessSince rates a photo's capture time against the moment the player set out to take it.
+func freshnessSince(meta utils.PhotoMetadata, since time.Time) string {
+	if meta.TakenAt.IsZero() {
+		return "undated"
+	}
+	slack := captureClockSkew
+	if !meta.TakenAtExact {
+		slack = captureUnknownZone
+	}
+	if meta.TakenAt.Before(since.Add(-slack)) || meta.TakenAt.After(time.Now().Add(slack)) {
+		return "stale"
+	}
+	return "fresh"
+}
+
+// freshnessPoints scales a submission's points by the rules for stale and undated photos.
+func freshnessPoints(rules models.ChallengeRules, freshness string, points int) int {
+	switch freshness {
+	case "stale":
+		return points * rules.StalePhotoPointsPercent / 100
+	case "undated":
+		return points * rules.UndatedPhotoPointsPercent / 100
+	}
+	return points
+}
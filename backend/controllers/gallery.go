@@ -17,11 +17,23 @@ import (
 
 // Postman: it can post photo in gallery page now and also like and unlike
 // Get All Gallery Posts
+// GET /gallery/posts?color=red
 func GetGalleryPosts(c *gin.Context) {
+	// Private group posts are only listed in their group's gallery
+	filter := publishedPosts(bson.M{"group_id": bson.M{"$exists": false}})
+	if color := c.Query("color"); color != "" {
+		name, ok := utils.ParseColorName(color)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown colour"})
+			return
+		}
+		filter["palette.name"] = name
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{})
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, filter)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database query failed"})
 		return
@@ -40,7 +52,11 @@ func GetGalleryPosts(c *gin.Context) {
 func ToggleLike(c *gin.Context) {
 	type LikeRequest struct {
 		PostID string `json:"post_id"`
-		Email  string `json:"email"`
+	}
+
+	userID, email, ok := currentUser(c)
+	if !ok {
+		return
 	}
 
 	var req LikeRequest
@@ -68,20 +84,27 @@ func ToggleLike(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
+	if !checkPostVisible(ctx, c, post) {
+		return
+	}
+	if post.UserID == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't like your own photo", "code": "own_post"})
+		return
+	}
 
 	var update bson.M
 	liked := false
-	for _, email := range post.Likes {
-		if email == req.Email {
+	for _, like := range post.Likes {
+		if like == email {
 			liked = true
 			break
 		}
 	}
 
 	if liked {
-		update = bson.M{"$pull": bson.M{"likes": req.Email}}
+		update = bson.M{"$pull": bson.M{"likes": email}}
 	} else {
-		update = bson.M{"$addToSet": bson.M{"likes": req.Email}}
+		update = bson.M{"$addToSet": bson.M{"likes": email}}
 	}
 
 	// Apply update
@@ -94,6 +117,8 @@ func ToggleLike(c *gin.Context) {
 	status := "liked"
 	if liked {
 		status = "unliked"
+	} else {
+		checkAchievements(ctx, post.UserID)
 	}
 	c.JSON(http.StatusOK, gin.H{"message": "Successfully " + status})
 }
@@ -127,6 +152,9 @@ func ShareGalleryPost(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 		return
 	}
+	if !checkPostVisible(ctx, c, post) {
+		return
+	}
 
 	// Compose shareable link
 	shareURL := fmt.Sprintf("https://photoquest.site/gallery/%s", req.PostID)
@@ -164,93 +192,36 @@ func GetGalleryPostByID(c *gin.Context) {
 		c.JSON(404, gin.H{"error": "Post not found"})
 		return
 	}
-
-	c.JSON(200, gin.H{
-		"id":            post.ID.Hex(),
-		"user_id":       post.UserID.Hex(),
-		"user_name":     post.UserName,
-		"user_avatar":   post.UserAvatar,
-		"image_url":     post.ImageURL,
-		"created_at":    post.CreatedAt.Format("2006-01-02 15:04"),
-		"choices":       post.Choices,
-		"likes_count":   len(post.Likes),
-		"correct_index": post.CorrectIndex, // optional: omit if frontend handles this only on submit
-	})
-}
-
-// Photo Detail Page: Multiple choice answer of each user
-// POST /gallery/answer
-func SubmitAnswer(c *gin.Context) {
-	type AnswerRequest struct {
-		PostID string `json:"post_id"`
-		UserID string `json:"user_id"`
-		Answer string `json:"answer"`
-	}
-
-	var req AnswerRequest
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input"})
+	if !checkPostVisible(ctx, c, post) {
 		return
 	}
+	viewerID, _ := primitive.ObjectIDFromHex(c.GetString("user_id"))
 
-	// Convert IDs
-	postOID, err := primitive.ObjectIDFromHex(req.PostID)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid post ID"})
-		return
+	response := gin.H{
+		"id":          post.ID.Hex(),
+		"user_id":     post.UserID.Hex(),
+		"user_name":   post.UserName,
+		"user_avatar": post.UserAvatar,
+		"image_url":   post.ImageURL,
+		"created_at":  post.CreatedAt.Format("2006-01-02 15:04"),
+		"likes_count": len(post.Likes),
 	}
-	userOID, err := primitive.ObjectIDFromHex(req.UserID)
-	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid user ID"})
-		return
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	// Find gallery post
-	var post models.GalleryPost
-	err = config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postOID}).Decode(&post)
-	if err != nil {
-		c.JSON(404, gin.H{"error": "Post not found"})
-		return
-	}
-
-	// Check if already answered
-	count, _ := config.DB.Collection("user_answers").CountDocuments(ctx, bson.M{
-		"user_id": userOID,
-		"post_id": postOID,
-	})
-	if count > 0 {
-		c.JSON(409, gin.H{"error": "You already answered this post"})
-		return
-	}
-
-	// Evaluate answer
-	isCorrect := post.Choices[post.CorrectIndex] == req.Answer
-
-	// Save answer attempt
-	answer := models.UserAnswer{
-		UserID:     userOID,
-		PostID:     postOID,
-		Answer:     req.Answer,
-		IsCorrect:  isCorrect,
-		AnsweredAt: time.Now().Unix(),
-	}
-	_, err = config.DB.Collection("user_answers").InsertOne(ctx, answer)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to save answer"})
-		return
-	}
-
-	// Award point if correct
-	if isCorrect {
-		_, _ = config.DB.Collection("users").UpdateByID(ctx, userOID, bson.M{"$inc": bson.M{"score": 20}})
+	// Guess posts keep their choices and answer from players. Viewing a post doesn't start the
+	// timed round; GET /challenge/guess/:id does, and only it shows the choices before answering.
+	if isGuessPost(post) {
+		extra := guessFormat(post, loadChallengeRules(ctx))
+		if answerVisible(ctx, post, viewerID) {
+			extra = correctAnswer(post)
+		}
+		for key, value := range extra {
+			response[key] = value
+		}
 	}
+	c.JSON(200, response)
+}
 
-	c.JSON(200, gin.H{
-		"correct":       isCorrect,
-		"correctAnswer": post.Choices[post.CorrectIndex],
-		"message":       "Answer submitted",
-	})
+// SubmitAnswer answers a guess post from the photo detail page
+// POST /gallery/answer
+func SubmitAnswer(c *gin.Context) {
+	submitGuess(c)
 }
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"fmt"
+
+	"photoquest/models"
+	"photoquest/utils"
+)
+
+// validateGeofence returns a message describing what is wrong, or "" for a usable geofence.
+func validateGeofence(g *models.Geofence) string {
+	if g == nil {
+		return ""
+	}
+	if g.Latitude < -90 || g.Latitude > 90 || g.Longitude < -180 || g.Longitude > 180 {
+		return "geofence latitude or longitude is out of range"
+	}
+	if g.RadiusKm <= 0 {
+		return "geofence radius_km must be positive"
+	}
+	return ""
+}
+
+// verifyPhotoLocation checks a photo's EXIF GPS position and capture time against an accepted
+// challenge's geofence. Unlike other challenges, a geofenced one needs a fresh photo. It returns
+// the coarse area to store on the post, or a message explaining why the photo was rejected.
+func verifyPhotoLocation(challenge models.UserChallenge, meta utils.PhotoMetadata) (*models.CoarseArea, string) {
+	fence := challenge.Geofence
+
+	if !meta.HasLocation {
+		return nil, "This challenge needs a photo with GPS location data. Turn on location for your camera and try again."
+	}
+
+	distance := utils.DistanceKm(fence.Latitude, fence.Longitude, meta.Latitude, meta.Longitude)
+	if distance > fence.RadiusKm {
+		return nil, fmt.Sprintf("Photo was taken %.1f km from %s, outside the %.1f km area", distance, fence.Label, fence.RadiusKm)
+	}
+
+	switch photoFreshness(meta, challenge) {
+	case "undated":
+		return nil, "This challenge needs a photo with its capture time recorded"
+	case "stale":
+		return nil, "Photo must be taken after you accepted this challenge"
+	}
+
+	return &models.CoarseArea{
+		Label:     fence.Label,
+		Latitude:  utils.CoarseCoordinate(meta.Latitude),
+		Longitude: utils.CoarseCoordinate(meta.Longitude),
+	}, ""
+}
@@ -0,0 +1,801 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Join codes skip characters that are easy to misread when written on a whiteboard
+const joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+func newJoinCode() (string, error) {
+	code := make([]byte, 8)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(joinCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = joinCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// loadGroup returns the group named in the URL, but only to its members.
+func loadGroup(ctx context.Context, c *gin.Context, userID primitive.ObjectID) (models.Group, bool) {
+	var group models.Group
+
+	groupID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid group ID"})
+		return group, false
+	}
+	err = config.DB.Collection("groups").FindOne(ctx, bson.M{"_id": groupID, "members.user_id": userID}).Decode(&group)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Group not found"})
+		return group, false
+	}
+	return group, true
+}
+
+// groupRole returns the user's role in the group, or "" when they are not a member.
+func groupRole(group models.Group, userID primitive.ObjectID) string {
+	for _, member := range group.Members {
+		if member.UserID == userID {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// checkPostVisible hides group posts from anyone outside the group, and unapproved or
+// moderator-hidden posts from everyone but their author.
+func checkPostVisible(ctx context.Context, c *gin.Context, post models.GalleryPost) bool {
+	unpublished := post.Hidden || post.ReviewStatus == "pending" || post.ReviewStatus == "rejected"
+	if post.GroupID == nil && !unpublished {
+		return true
+	}
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return false
+	}
+	// Held, rejected and hidden posts are only shown to their author
+	if unpublished {
+		if post.UserID != userID {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return false
+		}
+		return true
+	}
+	count, err := config.DB.Collection("groups").CountDocuments(ctx, bson.M{"_id": *post.GroupID, "members.user_id": userID})
+	if err != nil || count == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return false
+	}
+	return true
+}
+
+// publicGroup strips the join code for non-admins.
+func publicGroup(group models.Group, userID primitive.ObjectID) models.Group {
+	if groupRole(group, userID) != "admin" {
+		group.JoinCode = ""
+	}
+	return group
+}
+
+// CreateGroup creates a private group with the caller as its first admin
+// POST /groups
+func CreateGroup(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+	if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing group name"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		return
+	}
+
+	group := models.Group{
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Members: []models.GroupMember{
+			{UserID: userID, Username: user.Username, Role: "admin", JoinedAt: time.Now()},
+		},
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+	}
+
+	// Retry on the rare join code collision
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := newJoinCode()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate join code"})
+			return
+		}
+		group.JoinCode = code
+
+		res, err := config.DB.Collection("groups").InsertOne(ctx, group)
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create group"})
+			return
+		}
+		group.ID = res.InsertedID.(primitive.ObjectID)
+		c.JSON(http.StatusOK, gin.H{"message": "Group created", "group": group})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate join code"})
+}
+
+// GetMyGroups
+// GET /groups
+func GetMyGroups(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := config.DB.Collection("groups").Find(ctx, bson.M{"members.user_id": userID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch groups"})
+		return
+	}
+
+	var groups []models.Group
+	if err := cursor.All(ctx, &groups); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse groups"})
+		return
+	}
+
+	result := []models.Group{}
+	for _, group := range groups {
+		result = append(result, publicGroup(group, userID))
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetGroup
+// GET /groups/:id
+func GetGroup(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, publicGroup(group, userID))
+}
+
+// JoinGroup joins the group with the given join code
+// POST /groups/join
+func JoinGroup(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.Code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing join code"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		return
+	}
+
+	groups := config.DB.Collection("groups")
+	var group models.Group
+	err := groups.FindOne(ctx, bson.M{"join_code": strings.ToUpper(strings.TrimSpace(req.Code))}).Decode(&group)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid join code"})
+		return
+	}
+
+	res, err := groups.UpdateOne(ctx,
+		bson.M{"_id": group.ID, "members.user_id": bson.M{"$ne": userID}},
+		bson.M{"$push": bson.M{"members": models.GroupMember{
+			UserID:   userID,
+			Username: user.Username,
+			Role:     "member",
+			JoinedAt: time.Now(),
+		}}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join group"})
+		return
+	}
+	if res.ModifiedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You are already in this group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Joined " + group.Name, "group_id": group.ID.Hex()})
+}
+
+// removeGroupMember takes a player out of a group. The last member leaving deletes the group
+// and its prompts. Callers make sure the group keeps an admin.
+func removeGroupMember(ctx context.Context, group models.Group, userID primitive.ObjectID) error {
+	if len(group.Members) == 1 {
+		_, err := config.DB.Collection("groups").DeleteOne(ctx, bson.M{"_id": group.ID})
+		if err != nil {
+			return err
+		}
+		_, _ = config.DB.Collection("group_prompts").DeleteMany(ctx, bson.M{"group_id": group.ID})
+		return nil
+	}
+	_, err := config.DB.Collection("groups").UpdateByID(ctx, group.ID, bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}})
+	return err
+}
+
+// LeaveGroup
+// POST /groups/:id/leave
+func LeaveGroup(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	// A group always keeps at least one admin
+	if groupRole(group, userID) == "admin" {
+		admins := 0
+		for _, member := range group.Members {
+			if member.Role == "admin" {
+				admins++
+			}
+		}
+		if admins == 1 && len(group.Members) > 1 {
+			c.JSON(http.StatusConflict, gin.H{"error": "Make another member an admin before leaving"})
+			return
+		}
+	}
+
+	if err := removeGroupMember(ctx, group, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave group"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left " + group.Name})
+}
+
+// ResetJoinCode issues a new join code so the old one stops working
+// POST /groups/:id/code
+func ResetJoinCode(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+	if groupRole(group, userID) != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group admins can do this"})
+		return
+	}
+
+	for attempt := 0; attempt < 5; attempt++ {
+		code, err := newJoinCode()
+		if err != nil {
+			break
+		}
+		_, err = config.DB.Collection("groups").UpdateByID(ctx, group.ID, bson.M{"$set": bson.M{"join_code": code}})
+		if mongo.IsDuplicateKeyError(err) {
+			continue
+		}
+		if err != nil {
+			break
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "Join code reset", "join_code": code})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset join code"})
+}
+
+// SetGroupMemberRole promotes a member to admin or demotes an admin
+// PUT /groups/:id/members/:userId
+func SetGroupMemberRole(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	memberID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.BindJSON(&req); err != nil || (req.Role != "admin" && req.Role != "member") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be admin or member"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+	if groupRole(group, userID) != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group admins can do this"})
+		return
+	}
+	if memberID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+	if groupRole(group, memberID) == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not in this group"})
+		return
+	}
+
+	_, err = config.DB.Collection("groups").UpdateOne(ctx,
+		bson.M{"_id": group.ID, "members.user_id": memberID},
+		bson.M{"$set": bson.M{"members.$.role": req.Role}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated"})
+}
+
+// RemoveGroupMember
+// DELETE /groups/:id/members/:userId
+func RemoveGroupMember(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	memberID, err := primitive.ObjectIDFromHex(c.Param("userId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+	if groupRole(group, userID) != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group admins can do this"})
+		return
+	}
+	switch groupRole(group, memberID) {
+	case "":
+		c.JSON(http.StatusNotFound, gin.H{"error": "User is not in this group"})
+		return
+	case "admin":
+		c.JSON(http.StatusForbidden, gin.H{"error": "Demote an admin before removing them"})
+		return
+	}
+
+	_, err = config.DB.Collection("groups").UpdateByID(ctx, group.ID, bson.M{"$pull": bson.M{"members": bson.M{"user_id": memberID}}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove member"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Member removed"})
+}
+
+// CreateGroupPrompt
+// POST /groups/:id/prompts
+func CreateGroupPrompt(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.Challenge
+	if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.Prompt) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing prompt"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	if _, ok := loadChallengeRules(ctx).Modes[req.Mode]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown mode"})
+		return
+	}
+	if msg := validateGeofence(req.Geofence); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	colors, msg := normalizeColors(req.Colors)
+	if msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+	if groupRole(group, userID) != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group admins can do this"})
+		return
+	}
+
+	prompt := models.GroupPrompt{
+		GroupID:   group.ID,
+		Prompt:    strings.TrimSpace(req.Prompt),
+		Mode:      req.Mode,
+		Geofence:  req.Geofence,
+		Colors:    colors,
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+	}
+	res, err := config.DB.Collection("group_prompts").InsertOne(ctx, prompt)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create prompt"})
+		return
+	}
+	prompt.ID = res.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Prompt created", "prompt": prompt})
+}
+
+// GetGroupPrompts
+// GET /groups/:id/prompts
+func GetGroupPrompts(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := config.DB.Collection("group_prompts").Find(ctx, bson.M{"group_id": group.ID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch prompts"})
+		return
+	}
+
+	prompts := []models.GroupPrompt{}
+	if err := cursor.All(ctx, &prompts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse prompts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, prompts)
+}
+
+// DeleteGroupPrompt
+// DELETE /groups/:id/prompts/:promptId
+func DeleteGroupPrompt(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	promptID, err := primitive.ObjectIDFromHex(c.Param("promptId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prompt ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+	if groupRole(group, userID) != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group admins can do this"})
+		return
+	}
+
+	res, err := config.DB.Collection("group_prompts").DeleteOne(ctx, bson.M{"_id": promptID, "group_id": group.ID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete prompt"})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Prompt deleted"})
+}
+
+// AcceptGroupPrompt gives a member an accepted challenge whose post stays inside the group
+// when submitted through /challenge/submit
+// POST /groups/:id/prompts/:promptId/accept
+func AcceptGroupPrompt(c *gin.Context) {
+	userID, email, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	promptID, err := primitive.ObjectIDFromHex(c.Param("promptId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid prompt ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	var prompt models.GroupPrompt
+	err = config.DB.Collection("group_prompts").FindOne(ctx, bson.M{"_id": promptID, "group_id": group.ID}).Decode(&prompt)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Prompt not found"})
+		return
+	}
+
+	userChallenges := config.DB.Collection("user_challenges")
+	count, err := userChallenges.CountDocuments(ctx, bson.M{
+		"email":    email,
+		"group_id": group.ID,
+		"prompt":   prompt.Prompt,
+		"status":   bson.M{"$in": []string{"accepted", "completed"}},
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already accepted this prompt"})
+		return
+	}
+
+	now := time.Now()
+	res, err := userChallenges.InsertOne(ctx, models.UserChallenge{
+		Email:      email,
+		Date:       now.Format("2006-01-02"),
+		Prompt:     prompt.Prompt,
+		Mode:       prompt.Mode,
+		Status:     "accepted",
+		AcceptedAt: now,
+		GroupID:    &group.ID,
+		Geofence:   prompt.Geofence,
+		Colors:     prompt.Colors,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "Challenge accepted",
+		"challenge_id":  res.InsertedID,
+		"submit_before": now.Add(loadChallengeRules(ctx).SubmissionWindow()),
+	})
+}
+
+// GetGroupGallery lists the posts shared inside the group
+// GET /groups/:id/gallery
+func GetGroupGallery(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, publishedPosts(bson.M{"group_id": group.ID}), findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database query failed"})
+		return
+	}
+
+	posts := []models.GalleryPost{}
+	if err := cursor.All(ctx, &posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse posts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, posts)
+}
+
+// GetGroupLeaderboard ranks current members by the points they earned from group prompts
+// GET /groups/:id/leaderboard
+func GetGroupLeaderboard(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	memberIDs := []primitive.ObjectID{}
+	for _, member := range group.Members {
+		memberIDs = append(memberIDs, member.UserID)
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: publishedPosts(bson.M{
+			"group_id": group.ID,
+			"user_id":  bson.M{"$in": memberIDs},
+		})}},
+		{{Key: "$group", Value: bson.M{
+			"_id":         "$user_id",
+			"points":      bson.M{"$sum": "$points"},
+			"submissions": bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := config.DB.Collection("gallery_posts").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch group leaderboard"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		UserID      primitive.ObjectID `bson:"_id"`
+		Points      int                `bson:"points"`
+		Submissions int                `bson:"submissions"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decode error"})
+		return
+	}
+
+	// Every member is listed, including those who have not posted yet
+	points := map[primitive.ObjectID]int{}
+	submissions := map[primitive.ObjectID]int{}
+	for _, row := range rows {
+		points[row.UserID] = row.Points
+		submissions[row.UserID] = row.Submissions
+	}
+	members := append([]models.GroupMember{}, group.Members...)
+	sort.SliceStable(members, func(i, j int) bool {
+		return points[members[i].UserID] > points[members[j].UserID]
+	})
+
+	leaderboard := []gin.H{}
+	for i, member := range members {
+		leaderboard = append(leaderboard, gin.H{
+			"rank":        i + 1,
+			"user_id":     member.UserID.Hex(),
+			"username":    member.Username,
+			"points":      points[member.UserID],
+			"submissions": submissions[member.UserID],
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"group":       group.Name,
+		"leaderboard": leaderboard,
+	})
+}
+
+// DeleteGroupPost lets a group admin remove a post shared in their group
+// DELETE /groups/:id/posts/:postId
+func DeleteGroupPost(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	postID, err := primitive.ObjectIDFromHex(c.Param("postId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	group, ok := loadGroup(ctx, c, userID)
+	if !ok {
+		return
+	}
+	if groupRole(group, userID) != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only group admins can do this"})
+		return
+	}
+
+	var post models.GalleryPost
+	if err := config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postID, "group_id": group.ID}).Decode(&post); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	deleted, err := deletePost(ctx, post)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post removed"})
+}
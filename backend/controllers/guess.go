@@ -0,0 +1,795 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+	"photoquest/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Limits on what authors and players can type into a guess post.
+const (
+	minGuessChoices      = 2
+	maxGuessChoices      = 6
+	maxGuessAnswers      = 10
+	maxGuessAnswerLength = 100
+	maxGuessHintLength   = 200
+)
+
+// isGuessPost reports whether players answer the post, by picking a choice or typing the answer.
+// Queries match guess posts on the guess flag, which the guess queue indexes are filtered on.
+func isGuessPost(post models.GalleryPost) bool {
+	return post.Guess || len(post.Choices) > 0 || post.AnswerMode == "text"
+}
+
+// guessChoicesFromForm reads choice1..choice6 and correct_index from an upload form. The first
+// empty choice ends the list. Choices must differ ignoring case, since answer stats are counted
+// by choice text. It returns an error message for the client when the form is invalid.
+func guessChoicesFromForm(c *gin.Context) ([]string, int, string) {
+	var choices []string
+	seen := map[string]bool{}
+	for i := 1; i <= maxGuessChoices; i++ {
+		choice := strings.TrimSpace(c.PostForm(fmt.Sprintf("choice%d", i)))
+		if choice == "" {
+			break
+		}
+		if len(choice) > maxGuessAnswerLength {
+			return nil, 0, fmt.Sprintf("choice%d must be at most %d characters", i, maxGuessAnswerLength)
+		}
+		key := strings.ToLower(choice)
+		if seen[key] {
+			return nil, 0, fmt.Sprintf("choice%d repeats an earlier choice", i)
+		}
+		seen[key] = true
+		choices = append(choices, choice)
+	}
+	if len(choices) < minGuessChoices {
+		return nil, 0, fmt.Sprintf("Between %d and %d choices are required, starting at choice1", minGuessChoices, maxGuessChoices)
+	}
+
+	correctIndex, err := strconv.Atoi(c.PostForm("correct_index"))
+	if err != nil || correctIndex < 0 || correctIndex >= len(choices) {
+		return nil, 0, "Invalid correct_index"
+	}
+	return choices, correctIndex, ""
+}
+
+// guessAnswersFromForm reads the answer of a text guess post followed by its accepted
+// synonyms, given as repeated or comma-separated synonyms fields. Answers that normalize to
+// the same text are kept once.
+func guessAnswersFromForm(c *gin.Context) []string {
+	candidates := []string{c.PostForm("answer")}
+	for _, field := range c.PostFormArray("synonyms") {
+		candidates = append(candidates, strings.Split(field, ",")...)
+	}
+
+	var answers []string
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		key := utils.NormalizeAnswer(candidate)
+		if key == "" || seen[key] || len(candidate) > maxGuessAnswerLength {
+			if len(answers) == 0 {
+				return nil // the main answer itself is unusable
+			}
+			continue
+		}
+		seen[key] = true
+		answers = append(answers, candidate)
+		if len(answers) == maxGuessAnswers {
+			break
+		}
+	}
+	return answers
+}
+
+// guessRequest is the answer format both guess routes accept. challenge_id is the name the
+// challenge screen has always sent and is read as post_id.
+type guessRequest struct {
+	PostID        string `json:"post_id"`
+	ChallengeID   string `json:"challenge_id"`
+	SelectedIndex *int   `json:"selected_index"` // for multiple choice posts
+	Answer        string `json:"answer"`         // for text posts
+	RoundToken    string `json:"round_token"`    // issued when the post was opened
+}
+
+// submitGuess answers a guess post for the signed-in player. It is the only place guesses are
+// checked, stored and scored; the guess routes just call it.
+func submitGuess(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req guessRequest
+	if err := c.BindJSON(&req); err != nil || (req.SelectedIndex == nil && req.Answer == "") || req.RoundToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "post_id, round_token and selected_index or answer are required"})
+		return
+	}
+	if req.PostID == "" {
+		req.PostID = req.ChallengeID
+	}
+	postID, err := primitive.ObjectIDFromHex(req.PostID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var post models.GalleryPost
+	if err := config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postID}).Decode(&post); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if !checkPostVisible(ctx, c, post) {
+		return
+	}
+	if !isGuessPost(post) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This post is not a guess challenge"})
+		return
+	}
+	if post.UserID == userID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can't answer your own guess post", "code": "own_post"})
+		return
+	}
+
+	// Multiple choice posts take selected_index, text posts take the typed answer
+	answer := models.UserAnswer{UserID: userID, PostID: postID, SelectedIndex: -1}
+	if post.AnswerMode == "text" {
+		answer.Answer = strings.TrimSpace(req.Answer)
+		if answer.Answer == "" || len(answer.Answer) > maxGuessAnswerLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("answer must be 1 to %d characters", maxGuessAnswerLength)})
+			return
+		}
+		answer.IsCorrect = utils.AnswerMatches(answer.Answer, post.Answers)
+	} else {
+		if req.SelectedIndex == nil || *req.SelectedIndex < 0 || *req.SelectedIndex >= len(post.Choices) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answer index"})
+			return
+		}
+		answer.SelectedIndex = *req.SelectedIndex
+		answer.Answer = post.Choices[answer.SelectedIndex]
+		answer.IsCorrect = answer.SelectedIndex == post.CorrectIndex
+	}
+
+	tokenUser, tokenPost, startedAt, err := utils.ParseRoundToken(req.RoundToken)
+	if err != nil || tokenUser != userID.Hex() || tokenPost != postID.Hex() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid round token", "code": "invalid_round"})
+		return
+	}
+
+	rules := loadChallengeRules(ctx)
+	now := time.Now()
+	elapsed := now.Sub(startedAt)
+	expired := rules.GuessTimeLimitSeconds > 0 && elapsed > rules.GuessTimeLimit()+guessRoundGrace
+	answer.HintUsed = hintRevealed(ctx, userID, postID)
+	answer.AnswerMs = elapsed.Milliseconds()
+	answer.AnsweredAt = now.Unix()
+
+	// A late answer still closes the round, so the post isn't served again, but scores nothing
+	if expired {
+		answer.SelectedIndex = -1
+		answer.Answer = ""
+		answer.IsCorrect = false
+		answer.TimedOut = true
+	}
+	if answer.IsCorrect {
+		answer.Points = guessSpeedPoints(rules, rules.GuessPoints, elapsed)
+		if answer.HintUsed {
+			answer.Points -= answer.Points * rules.GuessHintCostPercent / 100
+		}
+	}
+
+	// The unique (user_id, post_id) index decides which of two concurrent answers counts
+	_, err = config.DB.Collection("user_answers").InsertOne(ctx, answer)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already answered this post"})
+		return
+	}
+	if err != nil {
+		fmt.Println("Failed to save answer:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save answer"})
+		return
+	}
+
+	rating := rateGuess(ctx, userID, post, answer.IsCorrect)
+	if expired {
+		response := correctAnswer(post)
+		response["error"] = "Time is up for this guess"
+		response["code"] = "round_expired"
+		response["guess_rating"] = rating
+		c.JSON(http.StatusGone, response)
+		return
+	}
+	rewardGuessAuthor(ctx, rules, post, userID)
+
+	message := "Incorrect answer. Try again!"
+	if answer.IsCorrect {
+		if err := awardPoints(ctx, userID, answer.Points); err != nil {
+			fmt.Println("Failed to update user score:", err)
+		}
+		message = fmt.Sprintf("Correct answer! You earned %d points!", answer.Points)
+	}
+
+	response := correctAnswer(post)
+	response["is_correct"] = answer.IsCorrect
+	response["points"] = answer.Points
+	response["message"] = message
+	response["answer"] = answer.Answer
+	response["answer_seconds"] = math.Round(elapsed.Seconds()*10) / 10
+	response["hint_used"] = answer.HintUsed
+	response["guess_rating"] = rating
+	response["achievements_unlocked"] = checkAchievements(ctx, userID)
+	if post.AnswerMode != "text" {
+		response["selected_index"] = answer.SelectedIndex
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// answerVisible reports whether the viewer may see a guess post's answer: its author always
+// can, and players can once they have answered it.
+func answerVisible(ctx context.Context, post models.GalleryPost, viewerID primitive.ObjectID) bool {
+	if post.UserID == viewerID {
+		return true
+	}
+	count, err := config.DB.Collection("user_answers").CountDocuments(ctx, bson.M{"user_id": viewerID, "post_id": post.ID})
+	return err == nil && count > 0
+}
+
+// correctAnswer reveals a guess post's answer, with its choices, once the player has answered.
+func correctAnswer(post models.GalleryPost) gin.H {
+	if post.AnswerMode == "text" {
+		return gin.H{"correct_answer": post.Answers[0]}
+	}
+	return gin.H{
+		"choices":        post.Choices,
+		"correct_index":  post.CorrectIndex,
+		"correct_answer": post.Choices[post.CorrectIndex],
+	}
+}
+
+// guessAuthorPoints is what the author earns for one more answer on a post. Nothing is paid
+// until the post has minAnswers answers; after that the payout is highest when half the players
+// get it right and falls to nothing for posts everyone or no one solves.
+func guessAuthorPoints(rules models.ChallengeRules, answers, correct int) int {
+	if answers < rules.GuessAuthorMinAnswers || answers == 0 {
+		return 0
+	}
+	rate := float64(correct) / float64(answers)
+	quality := 1 - math.Abs(rate-0.5)*2
+	return int(math.Round(float64(rules.GuessAuthorPoints) * quality))
+}
+
+// rewardGuessAuthor pays the post's author for a new answer, within the per-post and per-day
+// caps, so alt accounts answering each other's posts can only farm a bounded amount.
+func rewardGuessAuthor(ctx context.Context, rules models.ChallengeRules, post models.GalleryPost, answererID primitive.ObjectID) {
+	if post.UserID == answererID {
+		return
+	}
+	answers := config.DB.Collection("user_answers")
+	total, err := answers.CountDocuments(ctx, bson.M{"post_id": post.ID})
+	if err != nil {
+		fmt.Println("Failed to count answers for author reward:", err)
+		return
+	}
+	correct, err := answers.CountDocuments(ctx, bson.M{"post_id": post.ID, "is_correct": true})
+	if err != nil {
+		fmt.Println("Failed to count answers for author reward:", err)
+		return
+	}
+	points := guessAuthorPoints(rules, int(total), int(correct))
+	if points == 0 || points > rules.GuessAuthorDailyCap || points > rules.GuessAuthorPostCap {
+		return
+	}
+
+	// Daily cap: the unique (user_id, date) index rejects the upsert once the day is full
+	today := time.Now().Format("2006-01-02")
+	rewards := config.DB.Collection("author_rewards")
+	_, err = rewards.UpdateOne(ctx,
+		bson.M{"user_id": post.UserID, "date": today, "points": bson.M{"$lte": rules.GuessAuthorDailyCap - points}},
+		bson.M{"$inc": bson.M{"points": points}},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return
+	}
+	if err != nil {
+		fmt.Println("Failed to record author reward:", err)
+		return
+	}
+
+	// Per-post cap
+	res, err := config.DB.Collection("gallery_posts").UpdateOne(ctx,
+		bson.M{"_id": post.ID, "$or": bson.A{
+			bson.M{"author_points": bson.M{"$exists": false}},
+			bson.M{"author_points": bson.M{"$lte": rules.GuessAuthorPostCap - points}},
+		}},
+		bson.M{"$inc": bson.M{"author_points": points}},
+	)
+	if err != nil || res.MatchedCount == 0 {
+		if err != nil {
+			fmt.Println("Failed to record author points on post:", err)
+		}
+		_, _ = rewards.UpdateOne(ctx, bson.M{"user_id": post.UserID, "date": today}, bson.M{"$inc": bson.M{"points": -points}})
+		return
+	}
+
+	if err := awardPoints(ctx, post.UserID, points); err != nil {
+		fmt.Println("Failed to update author score:", err)
+	}
+}
+
+// openGuessPosts is the filter for guess posts a player may be served: published, outside
+// private groups and not their own.
+func openGuessPosts(userID primitive.ObjectID) bson.M {
+	return publishedPosts(bson.M{
+		"guess":    true,
+		"group_id": bson.M{"$exists": false},
+		"user_id":  bson.M{"$ne": userID},
+	})
+}
+
+// guessQueueFilter extends openGuessPosts for the guess queues: posts under moderation and
+// posts the player has reported are skipped as well.
+func guessQueueFilter(ctx context.Context, userID primitive.ObjectID) (bson.M, error) {
+	skipped, err := config.DB.Collection("moderation_cases").Distinct(ctx, "post_id", bson.M{"status": "open"})
+	if err != nil {
+		return nil, err
+	}
+	reported, err := config.DB.Collection("reports").Distinct(ctx, "post_id", bson.M{"reporter_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	filter := openGuessPosts(userID)
+	if skipped = append(skipped, reported...); len(skipped) > 0 {
+		filter["_id"] = bson.M{"$nin": skipped}
+	}
+	return filter, nil
+}
+
+// findUnansweredGuess runs filter against gallery_posts and returns the first post in order
+// the player has not answered, or nil. Answers are checked per candidate through the
+// (user_id, post_id) index, so the player's answer history is never loaded.
+func findUnansweredGuess(ctx context.Context, userID primitive.ObjectID, filter bson.M, stages ...bson.D) (*models.GalleryPost, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: filter}}}
+	pipeline = append(pipeline, stages...)
+	pipeline = append(pipeline,
+		bson.D{{Key: "$lookup", Value: bson.M{
+			"from": "user_answers",
+			"let":  bson.M{"post_id": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"user_id": userID, "$expr": bson.M{"$eq": bson.A{"$post_id", "$$post_id"}}}},
+				bson.M{"$limit": 1},
+				bson.M{"$project": bson.M{"_id": 1}},
+			},
+			"as": "answered",
+		}}},
+		bson.D{{Key: "$match", Value: bson.M{"answered.0": bson.M{"$exists": false}}}},
+		bson.D{{Key: "$limit", Value: 1}},
+	)
+
+	cursor, err := config.DB.Collection("gallery_posts").Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var posts []models.GalleryPost
+	if err := cursor.All(ctx, &posts); err != nil {
+		return nil, err
+	}
+	if len(posts) == 0 {
+		return nil, nil
+	}
+	return &posts[0], nil
+}
+
+// guessFormat describes how a guess post is answered, without giving anything away.
+func guessFormat(post models.GalleryPost, rules models.ChallengeRules) gin.H {
+	format := gin.H{"answer_mode": "choice", "has_hint": post.Hint != ""}
+	if post.AnswerMode == "text" {
+		format["answer_mode"] = "text"
+	}
+	if post.Hint != "" {
+		format["hint_cost_percent"] = rules.GuessHintCostPercent
+	}
+	return format
+}
+
+// guessChallengeResponse is the guess post as the guess screen shows it. Players get a timed
+// round instead of the answer; only the author and players who answered see it. This is the
+// one place rounds start.
+func guessChallengeResponse(ctx context.Context, post models.GalleryPost, viewerID primitive.ObjectID) gin.H {
+	rules := loadChallengeRules(ctx)
+	response := gin.H{
+		"id":         post.ID.Hex(),
+		"image_url":  post.ImageURL,
+		"prompt":     post.Prompt,
+		"choices":    post.Choices,
+		"difficulty": post.Difficulty,
+		"rating":     math.Round(ratingOrStart(post.Rating)),
+		"points":     rules.GuessPoints,
+		"created_at": post.CreatedAt.Format(time.RFC3339),
+		"author":     post.UserName,
+	}
+	for key, value := range guessFormat(post, rules) {
+		response[key] = value
+	}
+	if answerVisible(ctx, post, viewerID) {
+		for key, value := range correctAnswer(post) {
+			response[key] = value
+		}
+		return response
+	}
+	for key, value := range startGuessRound(ctx, viewerID, post, rules) {
+		response[key] = value
+	}
+	return response
+}
+
+// GetNextGuess serves the newest guess post the caller has not answered
+// GET /challenge/guess/next?difficulty=easy|medium|hard
+func GetNextGuess(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	difficulty := c.Query("difficulty")
+	if difficulty != "" && difficulty != "easy" && difficulty != "medium" && difficulty != "hard" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "difficulty must be easy, medium or hard"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	filter, err := guessQueueFilter(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find a guess post"})
+		return
+	}
+	if difficulty != "" {
+		filter["difficulty"] = difficulty
+	}
+
+	newest := bson.D{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: -1}}}}
+	post, err := findUnansweredGuess(ctx, userID, filter, newest)
+	if err != nil {
+		fmt.Println("Failed to find a guess post:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find a guess post"})
+		return
+	}
+	if post == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No unanswered guess posts left"})
+		return
+	}
+
+	c.JSON(http.StatusOK, guessChallengeResponse(ctx, *post, userID))
+}
+
+// guessRoundGrace absorbs network latency at the end of a timed round.
+const guessRoundGrace = 2 * time.Second
+
+// startGuessRound opens the player's timed round on a guess post and returns what the guess
+// screens add to their response: the signed round token and the deadline. The round starts the
+// first time the player opens the post, so opening it again doesn't reset the clock.
+func startGuessRound(ctx context.Context, userID primitive.ObjectID, post models.GalleryPost, rules models.ChallengeRules) gin.H {
+	startedAt := time.Now()
+	var view struct {
+		ViewedAt time.Time `bson:"viewed_at"`
+	}
+	err := config.DB.Collection("guess_views").FindOneAndUpdate(ctx,
+		bson.M{"user_id": userID, "post_id": post.ID},
+		bson.M{"$setOnInsert": bson.M{"viewed_at": startedAt}},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&view)
+	if err != nil {
+		fmt.Println("Failed to record guess view:", err)
+	} else {
+		startedAt = view.ViewedAt
+	}
+
+	token, err := utils.GenerateRoundToken(userID.Hex(), post.ID.Hex(), startedAt)
+	if err != nil {
+		fmt.Println("Failed to sign round token:", err)
+		return nil
+	}
+	round := gin.H{
+		"round_token":        token,
+		"round_started_at":   startedAt.Format(time.RFC3339),
+		"time_limit_seconds": rules.GuessTimeLimitSeconds,
+	}
+	if rules.GuessTimeLimitSeconds > 0 {
+		round["round_expires_at"] = startedAt.Add(rules.GuessTimeLimit()).Format(time.RFC3339)
+	}
+	return round
+}
+
+// guessSpeedPoints scales the points for a correct answer by how much of the time limit was
+// left: full points for an instant answer, falling to GuessSlowestPointsPercent at the limit.
+func guessSpeedPoints(rules models.ChallengeRules, points int, elapsed time.Duration) int {
+	limit := rules.GuessTimeLimit()
+	if limit <= 0 {
+		return points
+	}
+	remaining := 1 - math.Min(float64(elapsed)/float64(limit), 1)
+	percent := float64(rules.GuessSlowestPointsPercent) + float64(100-rules.GuessSlowestPointsPercent)*remaining
+	return int(math.Round(float64(points) * percent / 100))
+}
+
+// hintRevealed reports whether the player opened the post's hint during their round.
+func hintRevealed(ctx context.Context, userID, postID primitive.ObjectID) bool {
+	count, err := config.DB.Collection("guess_views").CountDocuments(ctx, bson.M{"user_id": userID, "post_id": postID, "hint_used": true})
+	return err == nil && count > 0
+}
+
+// RevealGuessHint shows a guess post's hint. Revealing it costs part of the points for a
+// correct answer, and it starts the player's round if they haven't opened the post yet.
+// POST /challenge/guess/:id/hint
+func RevealGuessHint(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	postID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var post models.GalleryPost
+	if err := config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postID}).Decode(&post); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if !checkPostVisible(ctx, c, post) {
+		return
+	}
+	if !isGuessPost(post) || post.Hint == "" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "This post has no hint"})
+		return
+	}
+	if post.UserID == userID {
+		c.JSON(http.StatusOK, gin.H{"hint": post.Hint})
+		return
+	}
+	answered, _ := config.DB.Collection("user_answers").CountDocuments(ctx, bson.M{"user_id": userID, "post_id": postID})
+	if answered > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already answered this post"})
+		return
+	}
+
+	rules := loadChallengeRules(ctx)
+	_, err = config.DB.Collection("guess_views").UpdateOne(ctx,
+		bson.M{"user_id": userID, "post_id": postID},
+		bson.M{
+			"$set":         bson.M{"hint_used": true},
+			"$setOnInsert": bson.M{"viewed_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reveal hint"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"hint":              post.Hint,
+		"hint_cost_percent": rules.GuessHintCostPercent,
+	})
+}
+
+// percent is part of whole as a percentage rounded to one decimal, or 0 for an empty whole.
+func percent(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(whole)) / 10
+}
+
+// GetGuessPostStats shows how players did on a guess post. The author can always see it;
+// other players only once they have answered, so it can't give the answer away.
+// GET /gallery/post/:id/stats
+func GetGuessPostStats(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	postID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var post models.GalleryPost
+	if err := config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postID}).Decode(&post); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if !checkPostVisible(ctx, c, post) {
+		return
+	}
+	if !isGuessPost(post) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "This post is not a guess challenge"})
+		return
+	}
+	if post.UserID != userID {
+		answered, _ := config.DB.Collection("user_answers").CountDocuments(ctx, bson.M{"user_id": userID, "post_id": postID})
+		if answered == 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Answer this post to see its statistics"})
+			return
+		}
+	}
+
+	// Older answers only stored the choice text, so the distribution is counted by text;
+	// timed-out answers have none and only count towards the totals
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"post_id": postID}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       "$answer",
+			"count":     bson.M{"$sum": 1},
+			"correct":   bson.M{"$sum": bson.M{"$cond": bson.A{"$is_correct", 1, 0}}},
+			"answer_ms": bson.M{"$sum": bson.M{"$ifNull": bson.A{"$answer_ms", 0}}},
+			"timed":     bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$answer_ms", 0}}, 1, 0}}},
+		}}},
+	}
+	cursor, err := config.DB.Collection("user_answers").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch answers"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		Answer   string `bson:"_id"`
+		Count    int    `bson:"count"`
+		Correct  int    `bson:"correct"`
+		AnswerMs int64  `bson:"answer_ms"`
+		Timed    int    `bson:"timed"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decode error"})
+		return
+	}
+
+	counts := map[string]int{}
+	total, correct, timed := 0, 0, 0
+	var totalMs int64
+	for _, row := range rows {
+		counts[row.Answer] += row.Count
+		total += row.Count
+		correct += row.Correct
+		timed += row.Timed
+		totalMs += row.AnswerMs
+	}
+
+	distribution := []gin.H{}
+	if post.AnswerMode == "text" {
+		// The most common typed answers, merged after normalizing
+		type typed struct {
+			answer  string
+			count   int
+			correct bool
+		}
+		byKey := map[string]*typed{}
+		for _, row := range rows {
+			key := utils.NormalizeAnswer(row.Answer)
+			if key == "" {
+				continue
+			}
+			if byKey[key] == nil {
+				byKey[key] = &typed{answer: row.Answer}
+			}
+			byKey[key].count += row.Count
+			byKey[key].correct = byKey[key].correct || row.Correct > 0
+		}
+		common := []*typed{}
+		for _, entry := range byKey {
+			common = append(common, entry)
+		}
+		sort.Slice(common, func(i, j int) bool { return common[i].count > common[j].count })
+		if len(common) > 10 {
+			common = common[:10]
+		}
+		for _, entry := range common {
+			distribution = append(distribution, gin.H{
+				"answer":  entry.answer,
+				"count":   entry.count,
+				"percent": percent(entry.count, total),
+				"correct": entry.correct,
+			})
+		}
+	}
+	for i, choice := range post.Choices {
+		distribution = append(distribution, gin.H{
+			"index":   i,
+			"choice":  choice,
+			"count":   counts[choice],
+			"percent": percent(counts[choice], total),
+			"correct": i == post.CorrectIndex,
+		})
+	}
+
+	var averageSeconds float64
+	if timed > 0 {
+		averageSeconds = math.Round(float64(totalMs)/float64(timed)/100) / 10
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"post_id":                postID.Hex(),
+		"answers":                total,
+		"correct":                correct,
+		"percent_correct":        percent(correct, total),
+		"distribution":           distribution,
+		"average_answer_seconds": averageSeconds,
+	})
+}
+
+// GetMyGuessStats sums up how players did on every guess post the caller has made
+// GET /profile/guess-stats
+func GetMyGuessStats(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetProjection(bson.M{"_id": 1})
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"user_id": userID, "guess": true}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch guess posts"})
+		return
+	}
+	var posts []models.GalleryPost
+	if err := cursor.All(ctx, &posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse guess posts"})
+		return
+	}
+	postIDs := []primitive.ObjectID{}
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	answers := config.DB.Collection("user_answers")
+	total, err := answers.CountDocuments(ctx, bson.M{"post_id": bson.M{"$in": postIDs}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count answers"})
+		return
+	}
+	correct, err := answers.CountDocuments(ctx, bson.M{"post_id": bson.M{"$in": postIDs}, "is_correct": true})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count answers"})
+		return
+	}
+
+	stumped := int(total - correct)
+	c.JSON(http.StatusOK, gin.H{
+		"guess_posts":     len(postIDs),
+		"answers":         total,
+		"correct":         correct,
+		"stumped":         stumped,
+		"percent_stumped": percent(stumped, int(total)),
+	})
+}
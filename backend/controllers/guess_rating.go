@@ -0,0 +1,195 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Players and guess posts are rated like chess players: every answer is a game between the
+// two, the player winning with a correct answer and the post with a wrong one.
+const (
+	guessRatingStart = models.GuessRatingStart
+	guessRatingK     = 32.0
+
+	// A post's difficulty comes from its rating once this many answers have rated it;
+	// until then the author's own choice stands.
+	guessTierMinAnswers = 10
+	guessTierEasyBelow  = 1100.0
+	guessTierHardFrom   = 1300.0
+)
+
+// ratingOrStart treats a missing rating as the starting rating.
+func ratingOrStart(rating float64) float64 {
+	if rating == 0 {
+		return guessRatingStart
+	}
+	return rating
+}
+
+// guessRatingChange is how much the player's rating moves after answering a post; the post
+// moves by the same amount the other way.
+func guessRatingChange(playerRating, postRating float64, correct bool) float64 {
+	expected := 1 / (1 + math.Pow(10, (postRating-playerRating)/400))
+	score := 0.0
+	if correct {
+		score = 1
+	}
+	return guessRatingK * (score - expected)
+}
+
+// rateGuess updates the player's and the post's ratings after an answer and returns the
+// player's new rating. Both updates add to whatever is stored, so concurrent answers on the
+// same post never overwrite each other.
+func rateGuess(ctx context.Context, userID primitive.ObjectID, post models.GalleryPost, correct bool) float64 {
+	var player models.User
+	findOptions := options.FindOne().SetProjection(bson.M{"guess_rating": 1})
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}, findOptions).Decode(&player); err != nil {
+		fmt.Println("Failed to load guess rating:", err)
+		return 0
+	}
+	playerRating := ratingOrStart(player.GuessRating)
+	change := guessRatingChange(playerRating, ratingOrStart(post.Rating), correct)
+
+	_, err := config.DB.Collection("users").UpdateByID(ctx, userID, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"guess_rating": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$guess_rating", guessRatingStart}}, change}},
+		}}},
+	})
+	if err != nil {
+		fmt.Println("Failed to update player guess rating:", err)
+	}
+
+	_, err = config.DB.Collection("gallery_posts").UpdateByID(ctx, post.ID, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"rating":        bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating", guessRatingStart}}, -change}},
+			"rated_answers": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rated_answers", 0}}, 1}},
+		}}},
+		// Keep the author's choice for reference, then replace it with the rating's tier
+		{{Key: "$set", Value: bson.M{
+			"declared_difficulty": bson.M{"$ifNull": bson.A{"$declared_difficulty", "$difficulty"}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"difficulty": bson.M{"$cond": bson.A{
+				bson.M{"$gte": bson.A{"$rated_answers", guessTierMinAnswers}},
+				bson.M{"$switch": bson.M{
+					"branches": bson.A{
+						bson.M{"case": bson.M{"$lt": bson.A{"$rating", guessTierEasyBelow}}, "then": "easy"},
+						bson.M{"case": bson.M{"$lt": bson.A{"$rating", guessTierHardFrom}}, "then": "medium"},
+					},
+					"default": "hard",
+				}},
+				"$difficulty",
+			}},
+		}}},
+	})
+	if err != nil {
+		fmt.Println("Failed to update post guess rating:", err)
+	}
+
+	return math.Round(playerRating + change)
+}
+
+// unrateGuess backs a removed answer out of the player's and the post's ratings. The change an
+// answer made isn't stored, so the opposite of the change it would make at today's ratings is
+// applied instead.
+func unrateGuess(ctx context.Context, userID, postID primitive.ObjectID, correct bool) error {
+	var player models.User
+	findOptions := options.FindOne().SetProjection(bson.M{"guess_rating": 1})
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}, findOptions).Decode(&player); err != nil {
+		return err
+	}
+	var post models.GalleryPost
+	findOptions = options.FindOne().SetProjection(bson.M{"rating": 1})
+	if err := config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postID}, findOptions).Decode(&post); err != nil {
+		return err
+	}
+	change := guessRatingChange(ratingOrStart(player.GuessRating), ratingOrStart(post.Rating), correct)
+
+	_, err := config.DB.Collection("users").UpdateByID(ctx, userID, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"guess_rating": bson.M{"$subtract": bson.A{bson.M{"$ifNull": bson.A{"$guess_rating", guessRatingStart}}, change}},
+		}}},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = config.DB.Collection("gallery_posts").UpdateOne(ctx,
+		bson.M{"_id": postID, "rated_answers": bson.M{"$gt": 0}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{
+			"rating":        bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating", guessRatingStart}}, change}},
+			"rated_answers": bson.M{"$subtract": bson.A{"$rated_answers", 1}},
+		}}}},
+	)
+	return err
+}
+
+// GetGuessForMe serves the unanswered guess post rated closest to the caller
+// GET /challenge/guess/for-me
+func GetGuessForMe(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var player models.User
+	findOptions := options.FindOne().SetProjection(bson.M{"guess_rating": 1})
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}, findOptions).Decode(&player); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	rating := ratingOrStart(player.GuessRating)
+	queue, err := guessQueueFilter(ctx, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find a guess post"})
+		return
+	}
+
+	// Walk the rating index up and down from the player's rating and take whichever
+	// unanswered post is closer
+	var closest *models.GalleryPost
+	for _, direction := range []struct {
+		bound bson.M
+		order int
+	}{
+		{bson.M{"$gte": rating}, 1},
+		{bson.M{"$lt": rating}, -1},
+	} {
+		filter := bson.M{"rating": direction.bound}
+		for key, value := range queue {
+			filter[key] = value
+		}
+		byRating := bson.D{{Key: "$sort", Value: bson.D{{Key: "rating", Value: direction.order}}}}
+		post, err := findUnansweredGuess(ctx, userID, filter, byRating)
+		if err != nil {
+			fmt.Println("Failed to find a guess post:", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find a guess post"})
+			return
+		}
+		if post != nil && (closest == nil || math.Abs(post.Rating-rating) < math.Abs(closest.Rating-rating)) {
+			closest = post
+		}
+	}
+	if closest != nil {
+		response := guessChallengeResponse(ctx, *closest, userID)
+		response["player_rating"] = math.Round(rating)
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	c.JSON(http.StatusNotFound, gin.H{"error": "You have answered every guess post"})
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"photoquest/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// currentUser reads the caller's ID and email from the JWT claims set by the middleware and
+// turns away accounts that are being deleted, whose tokens stay valid until they expire.
+// It writes the error response itself, so callers just return when ok is false; the load,
+// check and claim helpers that return an ok flag all follow the same convention.
+func currentUser(c *gin.Context) (primitive.ObjectID, string, bool) {
+	userID, email, ok := tokenUser(c)
+	if !ok {
+		return userID, email, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	count, err := config.DB.Collection("users").CountDocuments(ctx,
+		bson.M{"_id": userID, "deleting": true}, options.Count().SetLimit(1))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return primitive.NilObjectID, "", false
+	}
+	if count > 0 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "This account is being deleted", "code": "account_deleting"})
+		return primitive.NilObjectID, "", false
+	}
+	return userID, email, true
+}
+
+// tokenUser is currentUser without the deletion check, for the endpoints a player still needs
+// while their account is being deleted.
+func tokenUser(c *gin.Context) (primitive.ObjectID, string, bool) {
+	claims, exists := c.Get("claims")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return primitive.NilObjectID, "", false
+	}
+	userClaims := claims.(jwt.MapClaims)
+
+	userIDHex, ok := userClaims["user_id"].(string)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user_id"})
+		return primitive.NilObjectID, "", false
+	}
+	userID, err := primitive.ObjectIDFromHex(userIDHex)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ObjectID"})
+		return primitive.NilObjectID, "", false
+	}
+
+	email, _ := userClaims["email"].(string)
+	return userID, strings.ToLower(email), true
+}
@@ -0,0 +1,561 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+	"photoquest/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// expireHuntRuns closes the user's active runs whose deadline has passed.
+func expireHuntRuns(ctx context.Context, userID primitive.ObjectID) {
+	_, err := config.DB.Collection("user_hunts").UpdateMany(ctx,
+		bson.M{"user_id": userID, "status": "active", "deadline": bson.M{"$lt": time.Now()}},
+		bson.M{"$set": bson.M{"status": "expired"}},
+	)
+	if err != nil {
+		fmt.Println("Failed to expire hunt runs:", err)
+	}
+}
+
+// loadHuntRun returns the caller's run named in the URL.
+func loadHuntRun(ctx context.Context, c *gin.Context, userID primitive.ObjectID) (models.UserHunt, bool) {
+	var run models.UserHunt
+
+	runID, err := primitive.ObjectIDFromHex(c.Param("runId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid run ID"})
+		return run, false
+	}
+
+	expireHuntRuns(ctx, userID)
+
+	err = config.DB.Collection("user_hunts").FindOne(ctx, bson.M{"_id": runID, "user_id": userID}).Decode(&run)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hunt run not found"})
+		return run, false
+	}
+	return run, true
+}
+
+// huntHeld reports whether any step of the run is waiting for review. The completion bonus
+// of such a run is held with it.
+func huntHeld(run models.UserHunt) bool {
+	for _, step := range run.Steps {
+		if step.Held {
+			return true
+		}
+	}
+	return false
+}
+
+// heldHuntPoints is what a completed run still has to pay once its post is approved.
+func heldHuntPoints(run models.UserHunt) int {
+	points := run.CompletionBonus
+	for _, step := range run.Steps {
+		if step.Held {
+			points += step.Points
+		}
+	}
+	return points
+}
+
+// GetHunts lists the hunts players can start
+// GET /hunts
+func GetHunts(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := config.DB.Collection("hunts").Find(ctx, bson.M{}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hunts"})
+		return
+	}
+
+	hunts := []models.Hunt{}
+	if err := cursor.All(ctx, &hunts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse hunts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, hunts)
+}
+
+// StartHunt begins a timed run through a hunt
+// POST /hunts/:id/start
+func StartHunt(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	huntID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hunt ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var hunt models.Hunt
+	if err := config.DB.Collection("hunts").FindOne(ctx, bson.M{"_id": huntID}).Decode(&hunt); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hunt not found"})
+		return
+	}
+
+	expireHuntRuns(ctx, userID)
+
+	// Expired runs can be tried again, but each hunt pays out only once
+	userHunts := config.DB.Collection("user_hunts")
+	var existing models.UserHunt
+	err = userHunts.FindOne(ctx, bson.M{
+		"user_id": userID,
+		"hunt_id": huntID,
+		"status":  bson.M{"$in": []string{"active", "completed"}},
+	}).Decode(&existing)
+	if err == nil {
+		message := "You already have this hunt in progress"
+		if existing.Status == "completed" {
+			message = "You have already completed this hunt"
+		}
+		c.JSON(http.StatusConflict, gin.H{"error": message, "run_id": existing.ID.Hex()})
+		return
+	}
+	if err != mongo.ErrNoDocuments {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+
+	now := time.Now()
+	run := models.UserHunt{
+		HuntID:          huntID,
+		UserID:          userID,
+		Title:           hunt.Title,
+		Ordered:         hunt.Ordered,
+		StepPoints:      hunt.StepPoints,
+		CompletionBonus: hunt.CompletionBonus,
+		Status:          "active",
+		StartedAt:       now,
+		Deadline:        now.Add(time.Duration(hunt.TimeLimitHours) * time.Hour),
+	}
+	for _, prompt := range hunt.Steps {
+		run.Steps = append(run.Steps, models.UserHuntStep{Prompt: prompt})
+	}
+
+	res, err := userHunts.InsertOne(ctx, run)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start hunt"})
+		return
+	}
+	run.ID = res.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hunt started", "run": run})
+}
+
+// GetMyHuntRuns lists the caller's hunt runs, newest first
+// GET /hunts/runs
+func GetMyHuntRuns(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	expireHuntRuns(ctx, userID)
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "started_at", Value: -1}})
+	cursor, err := config.DB.Collection("user_hunts").Find(ctx, bson.M{"user_id": userID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hunt runs"})
+		return
+	}
+
+	runs := []models.UserHunt{}
+	if err := cursor.All(ctx, &runs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse hunt runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetHuntRun shows per-step progress of one run
+// GET /hunts/runs/:runId
+func GetHuntRun(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	run, ok := loadHuntRun(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	completed := 0
+	for _, step := range run.Steps {
+		if step.ImageURL != "" {
+			completed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"run":             run,
+		"completed_steps": completed,
+		"total_steps":     len(run.Steps),
+	})
+}
+
+// SubmitHuntStep uploads the photo for one step of an active run
+// POST /hunts/runs/:runId/steps/:step
+func SubmitHuntStep(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	run, ok := loadHuntRun(ctx, c, userID)
+	if !ok {
+		return
+	}
+	if run.Status != "active" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Hunt run is " + run.Status})
+		return
+	}
+
+	step, err := strconv.Atoi(c.Param("step"))
+	if err != nil || step < 0 || step >= len(run.Steps) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid step"})
+		return
+	}
+	if run.Steps[step].ImageURL != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "This step is already done"})
+		return
+	}
+	if run.Ordered {
+		for i := 0; i < step; i++ {
+			if run.Steps[i].ImageURL == "" {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Complete step %d first", i)})
+				return
+			}
+		}
+	}
+
+	// Get uploaded file
+	header, err := c.FormFile("photo")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded photo"})
+		return
+	}
+	src, _ := header.Open()
+	defer src.Close()
+
+	photo, err := io.ReadAll(src)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded photo"})
+		return
+	}
+
+	// Steps go through the same freshness and duplicate checks as challenge submissions,
+	// with the run's start standing in for accepting a challenge
+	rules := loadChallengeRules(ctx)
+	meta, _ := utils.ReadPhotoMetadata(photo)
+	done := models.UserHuntStep{
+		Prompt:      run.Steps[step].Prompt,
+		CompletedAt: time.Now(),
+		Freshness:   freshnessSince(meta, run.StartedAt),
+	}
+	done.Points = freshnessPoints(rules, done.Freshness, run.StepPoints)
+
+	fingerprint := fingerprintPhoto(ctx, photo, rules.DuplicateMaxDistance)
+	if rejectDuplicate(c, fingerprint, rules.DuplicatePolicy, userID) {
+		return
+	}
+	reused, err := reusedHuntPhoto(ctx, userID, fingerprint, rules.DuplicateMaxDistance)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check the photo"})
+		return
+	}
+	if reused {
+		c.JSON(http.StatusConflict, gin.H{"error": "You have already used this photo in a hunt"})
+		return
+	}
+
+	// A step that would be held for review keeps its points until the published run is approved
+	probe := models.GalleryPost{}
+	fingerprint.apply(&probe, rules.DuplicatePolicy)
+	done.PHash, done.PHashBands, done.DuplicateOf, done.Flags = probe.PHash, probe.PHashBands, probe.DuplicateOf, probe.Flags
+	if done.Freshness == "stale" {
+		done.Flags = append(done.Flags, "stale_photo")
+		probe.Flags = done.Flags
+	}
+	done.Held = needsReview(rules, probe)
+
+	imageURL, err := utils.UploadBytesToS3(photo, header)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upload to S3", "detail": err.Error()})
+		return
+	}
+	done.ImageURL = imageURL
+
+	// The upload is only kept once the step uses it
+	discardPhoto := func() {
+		if err := utils.DeleteFromS3(imageURL); err != nil {
+			fmt.Println("Failed to delete unused upload:", err)
+		}
+	}
+
+	// The filter guards against the same step being submitted twice or after the deadline
+	stepPath := fmt.Sprintf("steps.%d", step)
+	userHunts := config.DB.Collection("user_hunts")
+	res, err := userHunts.UpdateOne(ctx,
+		bson.M{
+			"_id":                   run.ID,
+			"status":                "active",
+			"deadline":              bson.M{"$gte": time.Now()},
+			stepPath + ".image_url": bson.M{"$exists": false},
+		},
+		bson.M{"$set": bson.M{stepPath: done}},
+	)
+	if err != nil {
+		discardPhoto()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save step"})
+		return
+	}
+	if res.ModifiedCount == 0 {
+		discardPhoto()
+		c.JSON(http.StatusConflict, gin.H{"error": "This step is already done or the hunt has ended"})
+		return
+	}
+
+	run.Steps[step] = done
+	points, pending := 0, 0
+	if done.Held {
+		pending = done.Points
+	} else {
+		points = done.Points
+	}
+
+	// Close the run once every step has a photo; only the request that flips the status pays the bonus
+	completed := false
+	res, err = userHunts.UpdateOne(ctx,
+		bson.M{
+			"_id":    run.ID,
+			"status": "active",
+			"steps": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+				"image_url": bson.M{"$exists": false},
+			}}},
+		},
+		bson.M{"$set": bson.M{"status": "completed", "completed_at": time.Now()}},
+	)
+	if err != nil {
+		fmt.Println("Failed to complete hunt run:", err)
+	} else if res.ModifiedCount > 0 {
+		completed = true
+		if huntHeld(run) {
+			pending += run.CompletionBonus
+		} else {
+			points += run.CompletionBonus
+		}
+	}
+
+	if points > 0 {
+		if err := awardPoints(ctx, userID, points); err != nil {
+			fmt.Println("Failed to update user score:", err)
+			// Don't return error to user since the step was saved successfully
+		}
+	}
+
+	message := "Step completed"
+	if completed {
+		message = "Hunt completed!"
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":           message,
+		"image_url":         imageURL,
+		"points":            points,
+		"pending_points":    pending,
+		"freshness":         done.Freshness,
+		"hunt_completed":    completed,
+		"duplicate_warning": duplicateWarning(fingerprint, rules.DuplicatePolicy, userID),
+	})
+}
+
+// PublishHuntRun shares a completed run in the gallery as one grouped post
+// POST /hunts/runs/:runId/publish
+func PublishHuntRun(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	run, ok := loadHuntRun(ctx, c, userID)
+	if !ok {
+		return
+	}
+	if run.Status != "completed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only completed hunts can be published"})
+		return
+	}
+	if run.PostID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "This hunt is already published", "post_id": run.PostID.Hex()})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		return
+	}
+
+	var images, prompts []string
+	var flags []string
+	var duplicateOf *primitive.ObjectID
+	for _, step := range run.Steps {
+		images = append(images, step.ImageURL)
+		prompts = append(prompts, step.Prompt)
+		for _, flag := range step.Flags {
+			if !slices.Contains(flags, flag) {
+				flags = append(flags, flag)
+			}
+		}
+		if duplicateOf == nil {
+			duplicateOf = step.DuplicateOf
+		}
+	}
+
+	runID := run.ID
+	gallery := models.GalleryPost{
+		UserID:      userID,
+		UserName:    user.Username,
+		UserAvatar:  user.AvatarURL,
+		ImageURL:    images[0],
+		Images:      images,
+		Task:        run.Title + ": " + strings.Join(prompts, ", "),
+		HuntRunID:   &runID,
+		DuplicateOf: duplicateOf,
+		Flags:       flags,
+		Likes:       []string{},
+		CreatedAt:   time.Now(),
+	}
+	// Points held back from the run are paid when a moderator approves the post
+	if huntHeld(run) {
+		gallery.ReviewStatus = "pending"
+		gallery.Points = heldHuntPoints(run)
+	}
+
+	res, err := config.DB.Collection("gallery_posts").InsertOne(ctx, gallery)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Gallery insert failed"})
+		return
+	}
+	postID := res.InsertedID.(primitive.ObjectID)
+
+	_, err = config.DB.Collection("user_hunts").UpdateByID(ctx, run.ID, bson.M{"$set": bson.M{"post_id": postID}})
+	if err != nil {
+		fmt.Println("Failed to link hunt run to post:", err)
+	}
+
+	if gallery.ReviewStatus == "pending" {
+		c.JSON(http.StatusOK, gin.H{
+			"message":        "Hunt submitted and waiting for review",
+			"post_id":        postID.Hex(),
+			"review_status":  gallery.ReviewStatus,
+			"pending_points": gallery.Points,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Hunt published", "post_id": postID.Hex()})
+}
+
+// CreateHunt
+// POST /admin/hunts
+func CreateHunt(c *gin.Context) {
+	var req models.Hunt
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" || len(req.Steps) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A hunt needs a title and at least two steps"})
+		return
+	}
+	for _, step := range req.Steps {
+		if strings.TrimSpace(step) == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Steps must not be empty"})
+			return
+		}
+	}
+	if req.TimeLimitHours < 1 || req.StepPoints < 0 || req.CompletionBonus < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "time_limit_hours must be at least 1 and points must not be negative"})
+		return
+	}
+	req.ID = primitive.NilObjectID
+	req.CreatedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := config.DB.Collection("hunts").InsertOne(ctx, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create hunt"})
+		return
+	}
+	req.ID = res.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hunt created", "hunt": req})
+}
+
+// DeleteHunt removes a hunt template. Runs already started keep their own copy of the steps.
+// DELETE /admin/hunts/:id
+func DeleteHunt(c *gin.Context) {
+	huntID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hunt ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	res, err := config.DB.Collection("hunts").DeleteOne(ctx, bson.M{"_id": huntID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete hunt"})
+		return
+	}
+	if res.DeletedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Hunt not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Hunt deleted"})
+}
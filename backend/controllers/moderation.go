@@ -0,0 +1,424 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+	"photoquest/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var reportReasons = map[string]bool{
+	"offensive":    true,
+	"spam":         true,
+	"wrong_answer": true, // a guess post whose "correct" choice is misleading
+	"copyright":    true,
+	"other":        true,
+}
+
+// logModeration appends a decision to the audit log. moderatorID is nil for automatic actions.
+func logModeration(ctx context.Context, moderationCase models.ModerationCase, moderatorID *primitive.ObjectID, action, note string) {
+	entry := models.ModerationLogEntry{
+		PostID:      moderationCase.PostID,
+		PostOwnerID: moderationCase.PostOwnerID,
+		ModeratorID: moderatorID,
+		Action:      action,
+		Note:        note,
+		CreatedAt:   time.Now(),
+	}
+	if !moderationCase.ID.IsZero() {
+		entry.CaseID = &moderationCase.ID
+	}
+	if _, err := config.DB.Collection("moderation_log").InsertOne(ctx, entry); err != nil {
+		fmt.Println("Failed to write moderation log:", err)
+	}
+}
+
+// ReportPost files a report against a post. Reports on the same post share one open case in
+// the moderation queue, and the post is hidden once the case reaches the report threshold.
+// POST /gallery/post/:id/report
+func ReportPost(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	postID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return
+	}
+
+	var req struct {
+		Reason  string `json:"reason"`
+		Details string `json:"details"`
+	}
+	if err := c.BindJSON(&req); err != nil || !reportReasons[req.Reason] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason must be offensive, spam, wrong_answer, copyright or other"})
+		return
+	}
+	if len(req.Details) > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "details must be at most 1000 characters"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var post models.GalleryPost
+	if err := config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postID}).Decode(&post); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if !checkPostVisible(ctx, c, post) {
+		return
+	}
+	if post.UserID == userID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You can't report your own post"})
+		return
+	}
+
+	now := time.Now()
+	_, err = config.DB.Collection("reports").InsertOne(ctx, models.Report{
+		PostID:     postID,
+		ReporterID: userID,
+		Reason:     req.Reason,
+		Details:    strings.TrimSpace(req.Details),
+		CreatedAt:  now,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already reported this post"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save report"})
+		return
+	}
+
+	// One open case per post, however many reports come in
+	var moderationCase models.ModerationCase
+	countReport := func() error {
+		return config.DB.Collection("moderation_cases").FindOneAndUpdate(ctx,
+			bson.M{"post_id": postID, "status": "open"},
+			bson.M{
+				"$inc": bson.M{"report_count": 1, "reasons." + req.Reason: 1},
+				"$set": bson.M{"last_report_at": now},
+				"$setOnInsert": bson.M{
+					"post_owner_id":   post.UserID,
+					"first_report_at": now,
+				},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+		).Decode(&moderationCase)
+	}
+	err = countReport()
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent first report opened the case; this one now updates it
+		err = countReport()
+	}
+	if err != nil {
+		fmt.Println("Failed to update moderation case:", err)
+		c.JSON(http.StatusOK, gin.H{"message": "Report received"})
+		return
+	}
+
+	threshold := loadChallengeRules(ctx).ReportHideThreshold
+	if threshold > 0 && moderationCase.ReportCount >= threshold && !moderationCase.AutoHidden {
+		res, err := config.DB.Collection("gallery_posts").UpdateOne(ctx,
+			bson.M{"_id": postID, "hidden": bson.M{"$ne": true}},
+			bson.M{"$set": bson.M{"hidden": true}},
+		)
+		if err != nil {
+			fmt.Println("Failed to auto-hide post:", err)
+		} else if res.ModifiedCount > 0 {
+			_, _ = config.DB.Collection("moderation_cases").UpdateByID(ctx, moderationCase.ID, bson.M{"$set": bson.M{"auto_hidden": true}})
+			logModeration(ctx, moderationCase, nil, "auto_hide", fmt.Sprintf("Reached %d reports", moderationCase.ReportCount))
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Report received"})
+}
+
+// GetModerationQueue lists report cases, most reported first
+// GET /moderation/reports?status=open|resolved
+func GetModerationQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", "open")
+	if status != "open" && status != "resolved" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open or resolved"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sort := bson.D{{Key: "report_count", Value: -1}, {Key: "first_report_at", Value: 1}}
+	if status == "resolved" {
+		sort = bson.D{{Key: "resolved_at", Value: -1}}
+	}
+	findOptions := options.Find().SetSort(sort).SetLimit(100)
+	cursor, err := config.DB.Collection("moderation_cases").Find(ctx, bson.M{"status": status}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation queue"})
+		return
+	}
+	cases := []models.ModerationCase{}
+	if err := cursor.All(ctx, &cases); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse moderation queue"})
+		return
+	}
+
+	// Attach the reported posts so moderators can judge without another request
+	postIDs := []primitive.ObjectID{}
+	for _, moderationCase := range cases {
+		postIDs = append(postIDs, moderationCase.PostID)
+	}
+	posts := map[primitive.ObjectID]models.GalleryPost{}
+	if len(postIDs) > 0 {
+		cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"_id": bson.M{"$in": postIDs}})
+		if err == nil {
+			var found []models.GalleryPost
+			if err := cursor.All(ctx, &found); err == nil {
+				for _, post := range found {
+					posts[post.ID] = post
+				}
+			}
+		}
+	}
+
+	queue := []gin.H{}
+	for _, moderationCase := range cases {
+		entry := gin.H{"case": moderationCase}
+		if post, ok := posts[moderationCase.PostID]; ok {
+			entry["post"] = post
+		}
+		queue = append(queue, entry)
+	}
+
+	c.JSON(http.StatusOK, queue)
+}
+
+// GetModerationCase returns a case with every report filed on it
+// GET /moderation/reports/:id
+func GetModerationCase(c *gin.Context) {
+	caseID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var moderationCase models.ModerationCase
+	if err := config.DB.Collection("moderation_cases").FindOne(ctx, bson.M{"_id": caseID}).Decode(&moderationCase); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Case not found"})
+		return
+	}
+
+	reportFilter := bson.M{"post_id": moderationCase.PostID, "created_at": bson.M{"$gte": moderationCase.FirstReportAt}}
+	if !moderationCase.ResolvedAt.IsZero() {
+		reportFilter["created_at"].(bson.M)["$lte"] = moderationCase.ResolvedAt
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}})
+	cursor, err := config.DB.Collection("reports").Find(ctx, reportFilter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+	reports := []models.Report{}
+	if err := cursor.All(ctx, &reports); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse reports"})
+		return
+	}
+
+	response := gin.H{"case": moderationCase, "reports": reports}
+	var post models.GalleryPost
+	if err := config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": moderationCase.PostID}).Decode(&post); err == nil {
+		response["post"] = post
+		// Moderators need the answer to judge wrong_answer reports
+		if isGuessPost(post) {
+			response["answer"] = correctAnswer(post)
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResolveModerationCase applies a moderator's decision to a reported post and closes the case:
+// hide keeps the post out of every feed, delete removes it, warn records a warning against the
+// author and emails them, and dismiss rejects the reports and un-hides an auto-hidden post.
+// POST /moderation/reports/:id/action
+func ResolveModerationCase(c *gin.Context) {
+	moderatorID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	caseID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid case ID"})
+		return
+	}
+
+	var req struct {
+		Action string `json:"action"`
+		Note   string `json:"note"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	switch req.Action {
+	case "hide", "delete", "warn", "dismiss":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "action must be hide, delete, warn or dismiss"})
+		return
+	}
+	req.Note = strings.TrimSpace(req.Note)
+	if req.Action == "warn" && req.Note == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A note explaining the warning is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Close the case first so two moderators can't both act on it
+	var moderationCase models.ModerationCase
+	err = config.DB.Collection("moderation_cases").FindOneAndUpdate(ctx,
+		bson.M{"_id": caseID, "status": "open"},
+		bson.M{"$set": bson.M{
+			"status":          "resolved",
+			"action":          req.Action,
+			"resolved_by":     moderatorID,
+			"resolved_at":     time.Now(),
+			"resolution_note": req.Note,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&moderationCase)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No open case found"})
+		return
+	}
+
+	posts := config.DB.Collection("gallery_posts")
+	switch req.Action {
+	case "hide":
+		_, err = posts.UpdateByID(ctx, moderationCase.PostID, bson.M{"$set": bson.M{"hidden": true}})
+	case "delete":
+		var post models.GalleryPost
+		if err = posts.FindOne(ctx, bson.M{"_id": moderationCase.PostID}).Decode(&post); err == nil {
+			_, err = deletePost(ctx, post)
+		}
+	case "warn":
+		var author models.User
+		err = config.DB.Collection("users").FindOneAndUpdate(ctx,
+			bson.M{"_id": moderationCase.PostOwnerID},
+			bson.M{"$inc": bson.M{"warnings": 1}},
+		).Decode(&author)
+		if err == nil {
+			body := fmt.Sprintf("Hi %s,\n\nA moderator reviewed reports about one of your PhotoQuest posts and issued a warning.\n\n%s", author.Username, req.Note)
+			if mailErr := utils.SendEmail(author.Email, "A warning about your PhotoQuest post", body); mailErr != nil {
+				fmt.Println("Failed to send warning email:", mailErr)
+			}
+		}
+	case "dismiss":
+		if moderationCase.AutoHidden {
+			_, err = posts.UpdateByID(ctx, moderationCase.PostID, bson.M{"$unset": bson.M{"hidden": ""}})
+		}
+	}
+	if err != nil {
+		fmt.Println("Failed to apply moderation action:", err)
+		_, _ = config.DB.Collection("moderation_cases").UpdateByID(ctx, caseID, bson.M{
+			"$set":   bson.M{"status": "open"},
+			"$unset": bson.M{"action": "", "resolved_by": "", "resolved_at": "", "resolution_note": ""},
+		})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to apply action"})
+		return
+	}
+
+	logModeration(ctx, moderationCase, &moderatorID, req.Action, req.Note)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Case resolved", "case": moderationCase})
+}
+
+// GetModerationLog lists moderation decisions, newest first
+// GET /moderation/log?post_id=...&moderator_id=...&user_id=...
+func GetModerationLog(c *gin.Context) {
+	filter := bson.M{}
+	for param, field := range map[string]string{"post_id": "post_id", "moderator_id": "moderator_id", "user_id": "post_owner_id"} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		id, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param})
+			return
+		}
+		filter[field] = id
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(200)
+	cursor, err := config.DB.Collection("moderation_log").Find(ctx, filter, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch moderation log"})
+		return
+	}
+	entries := []models.ModerationLogEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse moderation log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entries)
+}
+
+// SetUserRole makes a player a moderator or takes the role away. The change applies from the
+// player's next login.
+// PUT /admin/users/:id/role
+func SetUserRole(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := c.BindJSON(&req); err != nil || (req.Role != "user" && req.Role != "moderator") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "role must be user or moderator"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Admins are managed outside the API
+	res, err := config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": userID, "role": bson.M{"$ne": "admin"}},
+		bson.M{"$set": bson.M{"role": req.Role}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update role"})
+		return
+	}
+	if res.MatchedCount == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found or is an admin"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated"})
+}
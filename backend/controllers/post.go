@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+	"photoquest/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const maxCaptionLength = 300
+
+// isModerator reports whether the caller may act on other players' posts.
+func isModerator(c *gin.Context) bool {
+	role := c.GetString("role")
+	return role == "admin" || role == "moderator"
+}
+
+// loadOwnPost loads the post in the :id path parameter for its owner or a moderator.
+func loadOwnPost(ctx context.Context, c *gin.Context, userID primitive.ObjectID) (models.GalleryPost, bool) {
+	var post models.GalleryPost
+
+	postID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return post, false
+	}
+	if err := config.DB.Collection("gallery_posts").FindOne(ctx, bson.M{"_id": postID}).Decode(&post); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return post, false
+	}
+	if post.UserID != userID && !isModerator(c) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "You can only change your own posts"})
+		return post, false
+	}
+	return post, true
+}
+
+// deletePost removes a post and everything hanging off it: answers, guess rounds, the custom
+// challenge record, any open moderation case and the stored photos. Likes live on the post and
+// go with it. A grouped hunt post's photos stay, since the hunt run still shows them. Under the "revoke" policy the author loses the points the post earned them;
+// players who answered it keep theirs. It returns false if the post was already gone.
+func deletePost(ctx context.Context, post models.GalleryPost) (bool, error) {
+	res, err := config.DB.Collection("gallery_posts").DeleteOne(ctx, bson.M{"_id": post.ID})
+	if err != nil {
+		return false, err
+	}
+	if res.DeletedCount == 0 {
+		return false, nil
+	}
+
+	for collection, filter := range map[string]bson.M{
+		"user_answers":      {"post_id": post.ID},
+		"guess_views":       {"post_id": post.ID},
+		"custom_challenges": {"image_url": post.ImageURL},
+	} {
+		if _, err := config.DB.Collection(collection).DeleteMany(ctx, filter); err != nil {
+			fmt.Println("Failed to clean up", collection, "for deleted post:", err)
+		}
+	}
+	_, err = config.DB.Collection("moderation_cases").UpdateMany(ctx,
+		bson.M{"post_id": post.ID, "status": "open"},
+		bson.M{"$set": bson.M{"status": "resolved", "action": "post_deleted", "resolved_at": time.Now()}},
+	)
+	if err != nil {
+		fmt.Println("Failed to close moderation case for deleted post:", err)
+	}
+
+	if loadChallengeRules(ctx).DeletedPostPoints == "revoke" {
+		earned := post.AuthorPoints
+		// Held and rejected submissions never paid out
+		if post.ReviewStatus != "pending" && post.ReviewStatus != "rejected" {
+			earned += post.Points
+		}
+		if err := awardPoints(ctx, post.UserID, -earned); err != nil {
+			fmt.Println("Failed to revoke points for deleted post:", err)
+		}
+	}
+	if _, err := refreshUserStats(ctx, post.UserID); err != nil {
+		fmt.Println("Failed to recount stats after deleting post:", err)
+	}
+
+	images := map[string]bool{post.ImageURL: true}
+	for _, image := range post.Images {
+		images[image] = true
+	}
+	if post.HuntRunID != nil {
+		var run models.UserHunt
+		err := config.DB.Collection("user_hunts").FindOne(ctx, bson.M{"_id": *post.HuntRunID}).Decode(&run)
+		if err != nil && err != mongo.ErrNoDocuments {
+			// Without the run we can't tell which photos it still uses, so keep them all
+			fmt.Println("Failed to load hunt run of deleted post:", err)
+			return true, nil
+		}
+		for _, step := range run.Steps {
+			delete(images, step.ImageURL)
+		}
+	}
+	for image := range images {
+		if image == "" {
+			continue
+		}
+		if err := utils.DeleteFromS3(image); err != nil {
+			fmt.Println("Failed to delete photo of post", post.ID.Hex()+":", err)
+		}
+	}
+	return true, nil
+}
+
+// EditGalleryPost changes a post's caption, or the prompt of a guess post
+// PATCH /gallery/post/:id
+func EditGalleryPost(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Caption *string `json:"caption"`
+		Prompt  *string `json:"prompt"`
+	}
+	if err := c.BindJSON(&req); err != nil || (req.Caption == nil && req.Prompt == nil) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "caption or prompt is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	post, ok := loadOwnPost(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	set := bson.M{"edited_at": time.Now()}
+	if req.Caption != nil {
+		caption := strings.TrimSpace(*req.Caption)
+		if len(caption) > maxCaptionLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("caption must be at most %d characters", maxCaptionLength)})
+			return
+		}
+		set["caption"] = caption
+	}
+	if req.Prompt != nil {
+		// Challenge posts show the challenge's prompt, which isn't the author's to change
+		if !isGuessPost(post) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Only guess posts have an editable prompt"})
+			return
+		}
+		prompt := strings.TrimSpace(*req.Prompt)
+		if len(prompt) > maxCaptionLength {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("prompt must be at most %d characters", maxCaptionLength)})
+			return
+		}
+		set["prompt"] = prompt
+	}
+
+	if _, err := config.DB.Collection("gallery_posts").UpdateByID(ctx, post.ID, bson.M{"$set": set}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post"})
+		return
+	}
+	if post.UserID != userID {
+		logModeration(ctx, models.ModerationCase{PostID: post.ID, PostOwnerID: post.UserID}, &userID, "edit", "")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post updated"})
+}
+
+// DeleteGalleryPost deletes a post with its answers and photos
+// DELETE /gallery/post/:id
+func DeleteGalleryPost(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	post, ok := loadOwnPost(ctx, c, userID)
+	if !ok {
+		return
+	}
+
+	deleted, err := deletePost(ctx, post)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post"})
+		return
+	}
+	if !deleted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+		return
+	}
+	if post.UserID != userID {
+		logModeration(ctx, models.ModerationCase{PostID: post.ID, PostOwnerID: post.UserID}, &userID, "delete", "")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Post deleted"})
+}
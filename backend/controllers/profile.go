@@ -21,61 +21,67 @@ import (
 	"photoquest/models"
 )
 
-func GetProfile(c *gin.Context) {
-	userID := c.MustGet("user_id").(string)
-	objID, _ := primitive.ObjectIDFromHex(userID)
-
-	ctx := context.TODO()
-
-	// Get user data
-	var user models.User
-	err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user)
-	if err != nil {
-		c.JSON(404, gin.H{"error": "User not found"})
-		return
-	}
-
+// refreshUserStats recounts the user's photos and the likes they received and stores the result.
+func refreshUserStats(ctx context.Context, userID primitive.ObjectID) (*models.UserStats, error) {
 	// Calculate total photos uploaded
-	photosCount, err := config.DB.Collection("gallery_posts").CountDocuments(ctx, bson.M{"user_id": objID})
+	photosCount, err := config.DB.Collection("gallery_posts").CountDocuments(ctx, bson.M{"user_id": userID})
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to count photos"})
-		return
+		return nil, err
 	}
 
 	// Calculate total likes received
-	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"user_id": objID})
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"user_id": userID})
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to fetch posts"})
-		return
+		return nil, err
 	}
 	defer cursor.Close(ctx)
 
 	var totalLikes int
 	var posts []models.GalleryPost
 	if err = cursor.All(ctx, &posts); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to parse posts"})
-		return
+		return nil, err
 	}
 
 	for _, post := range posts {
 		totalLikes += len(post.Likes)
 	}
 
-	// Update user stats
-	user.Stats = &models.UserStats{
+	stats := &models.UserStats{
 		TotalPhotosUploaded: int(photosCount),
 		TotalLikesReceived:  totalLikes,
 	}
-
-	// Update stats in database
-	_, err = config.DB.Collection("users").UpdateByID(ctx, objID, bson.M{
-		"$set": bson.M{"stats": user.Stats},
+	_, err = config.DB.Collection("users").UpdateByID(ctx, userID, bson.M{
+		"$set": bson.M{"stats": stats},
 	})
+	return stats, err
+}
+
+func GetProfile(c *gin.Context) {
+	userID := c.MustGet("user_id").(string)
+	objID, _ := primitive.ObjectIDFromHex(userID)
+
+	ctx := context.TODO()
+
+	// Get user data
+	var user models.User
+	err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": objID}).Decode(&user)
+	if err != nil {
+		c.JSON(404, gin.H{"error": "User not found"})
+		return
+	}
+
+	user.Stats, err = refreshUserStats(ctx, objID)
 	if err != nil {
 		c.JSON(500, gin.H{"error": "Failed to update stats"})
 		return
 	}
 
+	user.Achievements, err = getUserAchievements(ctx, objID)
+	if err != nil {
+		c.JSON(500, gin.H{"error": "Failed to fetch achievements"})
+		return
+	}
+
 	user.Password = "" // hide password
 	c.JSON(http.StatusOK, user)
 }
@@ -271,16 +277,3 @@ func uploadToS3(fileData []byte, filename string, fileHeader *multipart.FileHead
 	url := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, filename)
 	return url, nil
 }
-
-func DeleteAccount(c *gin.Context) {
-	userID := c.MustGet("user_id").(string)
-	objID, _ := primitive.ObjectIDFromHex(userID)
-
-	_, err := config.DB.Collection("users").DeleteOne(context.TODO(), bson.M{"_id": objID})
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to delete account"})
-		return
-	}
-
-	c.JSON(200, gin.H{"message": "Account deleted"})
-}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+	"photoquest/utils"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// publishedPosts narrows a gallery_posts filter to the posts feeds, leaderboards and
+// achievements count: not held or rejected in review, and not hidden by a moderator.
+func publishedPosts(filter bson.M) bson.M {
+	filter["review_status"] = bson.M{"$nin": []string{"pending", "rejected"}}
+	filter["hidden"] = bson.M{"$ne": true}
+	return filter
+}
+
+// needsReview reports whether the review mode holds this submission for a moderator.
+func needsReview(rules models.ChallengeRules, post models.GalleryPost) bool {
+	switch rules.ReviewMode {
+	case "all":
+		return true
+	case "flagged":
+		return len(post.Flags) > 0 || post.ColorCheck == "needs_review"
+	}
+	return false
+}
+
+// publishSubmission grants everything a completed challenge earns: points through the scoring
+// path, the duel entry, team progress and achievements. It runs on submit, or on approval for
+// held posts.
+func publishSubmission(ctx context.Context, post models.GalleryPost, challenge models.UserChallenge) []models.UserAchievement {
+	if err := awardPoints(ctx, post.UserID, post.Points); err != nil {
+		fmt.Println("Failed to update user score:", err)
+	}
+	if challenge.DuelID != nil {
+		attachDuelPost(ctx, *challenge.DuelID, post.UserID, post.ID)
+	}
+	if challenge.TeamChallengeID != nil {
+		contributeToTeamChallenge(ctx, *challenge.TeamChallengeID, post.UserID)
+	}
+	return checkAchievements(ctx, post.UserID)
+}
+
+// notifyReviewResult emails the submitter the moderator's decision.
+func notifyReviewResult(ctx context.Context, post models.GalleryPost, approved bool, reason string) {
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": post.UserID}).Decode(&user); err != nil {
+		fmt.Println("Failed to load submitter for review notice:", err)
+		return
+	}
+
+	subject := "Your PhotoQuest submission was approved"
+	body := fmt.Sprintf("Hi %s,\n\nYour photo for \"%s\" was approved and you earned %d points.", user.Username, post.Task, post.Points)
+	if !approved {
+		subject = "Your PhotoQuest submission was not approved"
+		body = fmt.Sprintf("Hi %s,\n\nYour photo for \"%s\" was not approved.\nReason: %s", user.Username, post.Task, reason)
+	}
+	if err := utils.SendEmail(user.Email, subject, body); err != nil {
+		fmt.Println("Failed to send review notice:", err)
+	}
+}
+
+// claimReview moves a pending post to its final review state. Only one moderator's decision
+// can win, so the post is returned only to the caller that made the change.
+func claimReview(ctx context.Context, c *gin.Context, status, reason string) (models.GalleryPost, bool) {
+	var post models.GalleryPost
+
+	moderatorID, _, ok := currentUser(c)
+	if !ok {
+		return post, false
+	}
+	postID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid post ID"})
+		return post, false
+	}
+
+	err = config.DB.Collection("gallery_posts").FindOneAndUpdate(ctx,
+		bson.M{"_id": postID, "review_status": "pending"},
+		bson.M{"$set": bson.M{
+			"review_status": status,
+			"review_reason": reason,
+			"reviewed_by":   moderatorID,
+			"reviewed_at":   time.Now(),
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&post)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No pending submission found"})
+		return post, false
+	}
+	return post, true
+}
+
+// GetReviewQueue lists held submissions, oldest first
+// GET /moderation/reviews?status=pending|approved|rejected
+func GetReviewQueue(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+	if status != "pending" && status != "approved" && status != "rejected" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, approved or rejected"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sort := 1
+	if status != "pending" {
+		sort = -1
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: sort}}).SetLimit(100)
+	cursor, err := config.DB.Collection("gallery_posts").Find(ctx, bson.M{"review_status": status}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch review queue"})
+		return
+	}
+
+	posts := []models.GalleryPost{}
+	if err := cursor.All(ctx, &posts); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse posts"})
+		return
+	}
+
+	pending, _ := config.DB.Collection("gallery_posts").CountDocuments(ctx, bson.M{"review_status": "pending"})
+	c.JSON(http.StatusOK, gin.H{
+		"posts":   posts,
+		"pending": pending,
+	})
+}
+
+// ApproveSubmission publishes a held post and grants its points
+// POST /moderation/reviews/:id/approve
+func ApproveSubmission(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	post, ok := claimReview(ctx, c, "approved", "")
+	if !ok {
+		return
+	}
+
+	var challenge models.UserChallenge
+	if post.ChallengeID != nil {
+		err := config.DB.Collection("user_challenges").FindOne(ctx, bson.M{"_id": *post.ChallengeID}).Decode(&challenge)
+		if err != nil {
+			fmt.Println("Failed to load challenge for approved post:", err)
+		}
+	}
+	unlocked := publishSubmission(ctx, post, challenge)
+	notifyReviewResult(ctx, post, true, "")
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":               "Submission approved",
+		"points":                post.Points,
+		"achievements_unlocked": unlocked,
+	})
+}
+
+// RejectSubmission keeps a held post out of the gallery
+// POST /moderation/reviews/:id/reject
+func RejectSubmission(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A reason is required"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	post, ok := claimReview(ctx, c, "rejected", strings.TrimSpace(req.Reason))
+	if !ok {
+		return
+	}
+	notifyReviewResult(ctx, post, false, post.ReviewReason)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Submission rejected"})
+}
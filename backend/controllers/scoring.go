@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"context"
+
+	"photoquest/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// awardPoints adds points to the user's total score, and to their team's score when they are
+// on one. Every handler that grants points goes through here so scoring stays in one place.
+func awardPoints(ctx context.Context, userID primitive.ObjectID, points int) error {
+	if points == 0 {
+		return nil
+	}
+	var user struct {
+		TeamID *primitive.ObjectID `bson:"team_id"`
+	}
+	err := config.DB.Collection("users").FindOneAndUpdate(ctx,
+		bson.M{"_id": userID},
+		bson.M{"$inc": bson.M{"total_score": points}},
+		options.FindOneAndUpdate().SetProjection(bson.M{"team_id": 1}),
+	).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil || user.TeamID == nil {
+		return err
+	}
+	_, err = config.DB.Collection("teams").UpdateByID(ctx, *user.TeamID, bson.M{"$inc": bson.M{"member_points": points}})
+	return err
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RemoveSelfInteractions cleans up likes and guess answers players gave their own posts
+// before the rules blocked them. Self answers are deleted and the points they earned are taken
+// back: the recorded points, the current guess_points for answers from before points were
+// recorded, or models.LegacyGuessPoints for correct answers from the original endpoints,
+// which have no selected_index. Answers on rated posts are also backed out of both ratings.
+// Authors were never paid for answers to their own posts, so there are no author rewards to
+// undo. The like stats of everyone affected are then recounted. With dry_run=true nothing is
+// changed.
+// POST /admin/self-interactions/cleanup?dry_run=true
+func RemoveSelfInteractions(c *gin.Context) {
+	dryRun := c.Query("dry_run") == "true"
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	// Likes are stored as emails, so match each player's email against their own posts
+	cursor, err := config.DB.Collection("users").Find(ctx, bson.M{}, options.Find().SetProjection(bson.M{"_id": 1, "email": 1}))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch users"})
+		return
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse users"})
+		return
+	}
+
+	posts := config.DB.Collection("gallery_posts")
+	selfLiked := map[primitive.ObjectID]bool{}
+	likesRemoved := int64(0)
+	for _, user := range users {
+		if user.Email == "" {
+			continue
+		}
+		filter := bson.M{"user_id": user.ID, "likes": user.Email}
+		var count int64
+		if dryRun {
+			count, err = posts.CountDocuments(ctx, filter)
+		} else {
+			var res *mongo.UpdateResult
+			res, err = posts.UpdateMany(ctx, filter, bson.M{"$pull": bson.M{"likes": user.Email}})
+			if res != nil {
+				count = res.ModifiedCount
+			}
+		}
+		if err != nil {
+			fmt.Println("Failed to remove self-likes for", user.ID.Hex()+":", err)
+			continue
+		}
+		if count > 0 {
+			likesRemoved += count
+			selfLiked[user.ID] = true
+		}
+	}
+
+	// Answers whose player is also the post's author
+	pipeline := mongo.Pipeline{
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "gallery_posts",
+			"localField":   "post_id",
+			"foreignField": "_id",
+			"as":           "post",
+		}}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{
+			"$eq": bson.A{"$user_id", bson.M{"$first": "$post.user_id"}},
+		}}}},
+		{{Key: "$addFields", Value: bson.M{
+			"legacy":          bson.M{"$eq": bson.A{bson.M{"$type": "$selected_index"}, "missing"}},
+			"points_recorded": bson.M{"$ne": bson.A{bson.M{"$type": "$points"}, "missing"}},
+			"rated":           bson.M{"$gt": bson.A{bson.M{"$ifNull": bson.A{bson.M{"$first": "$post.rated_answers"}, 0}}, 0}},
+		}}},
+		{{Key: "$project", Value: bson.M{"post": 0}}},
+	}
+	cursor, err = config.DB.Collection("user_answers").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find self answers"})
+		return
+	}
+	var answers []struct {
+		models.UserAnswer `bson:",inline"`
+		Legacy            bool `bson:"legacy"`
+		PointsRecorded    bool `bson:"points_recorded"`
+		Rated             bool `bson:"rated"`
+	}
+	if err := cursor.All(ctx, &answers); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse self answers"})
+		return
+	}
+
+	guessPoints := loadChallengeRules(ctx).GuessPoints
+	answersRemoved, pointsRemoved, legacyAnswers, ratingsReverted := 0, 0, 0, 0
+	for _, answer := range answers {
+		points := 0
+		switch {
+		case answer.Legacy:
+			legacyAnswers++
+			if answer.IsCorrect {
+				points = models.LegacyGuessPoints
+			}
+		case answer.IsCorrect && answer.PointsRecorded:
+			points = answer.Points
+		case answer.IsCorrect:
+			points = guessPoints
+		}
+		if !dryRun {
+			// Only the call that deletes the answer takes its points back, so reruns are safe
+			res, err := config.DB.Collection("user_answers").DeleteOne(ctx, bson.M{"_id": answer.ID})
+			if err != nil || res.DeletedCount == 0 {
+				continue
+			}
+			if err := awardPoints(ctx, answer.UserID, -points); err != nil {
+				fmt.Println("Failed to take back self-answer points:", err)
+			}
+			// Legacy answers predate ratings
+			if answer.Rated && !answer.Legacy {
+				if err := unrateGuess(ctx, answer.UserID, answer.PostID, answer.IsCorrect); err != nil {
+					fmt.Println("Failed to revert self-answer rating:", err)
+				}
+			}
+		}
+		if answer.Rated && !answer.Legacy {
+			ratingsReverted++
+		}
+		answersRemoved++
+		pointsRemoved += points
+	}
+
+	if !dryRun {
+		for userID := range selfLiked {
+			if _, err := refreshUserStats(ctx, userID); err != nil {
+				fmt.Println("Failed to recount stats for", userID.Hex()+":", err)
+			}
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":                  dryRun,
+		"likes_removed":            likesRemoved,
+		"answers_removed":          answersRemoved,
+		"points_removed":           pointsRemoved,
+		"legacy_answers":           legacyAnswers,
+		"legacy_points_per_answer": models.LegacyGuessPoints, // taken back for each correct legacy answer
+		"ratings_reverted":         ratingsReverted,
+		"users_affected":           len(selfLiked),
+	})
+}
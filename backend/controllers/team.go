@@ -0,0 +1,640 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"photoquest/config"
+	"photoquest/models"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// loadTeam returns the team named in the URL.
+func loadTeam(ctx context.Context, c *gin.Context) (models.Team, bool) {
+	var team models.Team
+
+	teamID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid team ID"})
+		return team, false
+	}
+	if err := config.DB.Collection("teams").FindOne(ctx, bson.M{"_id": teamID}).Decode(&team); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Team not found"})
+		return team, false
+	}
+	return team, true
+}
+
+// teamRole returns the user's role in the team, or "" when they are not a member.
+func teamRole(team models.Team, userID primitive.ObjectID) string {
+	for _, member := range team.Members {
+		if member.UserID == userID {
+			return member.Role
+		}
+	}
+	return ""
+}
+
+// contributeToTeamChallenge counts a member's submission toward the team goal and pays the
+// team bonus when it is reached. Only the submission that completes the goal pays out.
+func contributeToTeamChallenge(ctx context.Context, teamChallengeID, userID primitive.ObjectID) {
+	teamChallenges := config.DB.Collection("team_challenges")
+
+	var challenge models.TeamChallenge
+	err := teamChallenges.FindOneAndUpdate(ctx,
+		bson.M{"_id": teamChallengeID, "status": "active", "deadline": bson.M{"$gt": time.Now()}},
+		bson.M{
+			"$inc":      bson.M{"progress": 1},
+			"$addToSet": bson.M{"contributors": userID},
+		},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&challenge)
+	if err != nil {
+		if err != mongo.ErrNoDocuments {
+			fmt.Println("Failed to update team challenge:", err)
+		}
+		return
+	}
+	if challenge.Progress < challenge.Goal {
+		return
+	}
+
+	res, err := teamChallenges.UpdateOne(ctx,
+		bson.M{"_id": teamChallengeID, "status": "active"},
+		bson.M{"$set": bson.M{"status": "completed"}},
+	)
+	if err != nil || res.ModifiedCount == 0 {
+		return
+	}
+	_, err = config.DB.Collection("teams").UpdateByID(ctx, challenge.TeamID, bson.M{"$inc": bson.M{"bonus_points": challenge.BonusPoints}})
+	if err != nil {
+		fmt.Println("Failed to award team bonus:", err)
+	}
+}
+
+// CreateTeam creates a team with the caller as captain
+// POST /teams
+func CreateTeam(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := c.BindJSON(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing team name"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		return
+	}
+	if user.TeamID != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "Leave your current team first"})
+		return
+	}
+
+	team := models.Team{
+		Name: strings.TrimSpace(req.Name),
+		Members: []models.TeamMember{
+			{UserID: userID, Username: user.Username, Role: "captain", JoinedAt: time.Now()},
+		},
+		Invites:   []primitive.ObjectID{},
+		CreatedAt: time.Now(),
+	}
+	res, err := config.DB.Collection("teams").InsertOne(ctx, team)
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Team name is already taken"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create team"})
+		return
+	}
+	team.ID = res.InsertedID.(primitive.ObjectID)
+
+	// Claim membership; the filter stops a concurrent join from putting the user in two teams
+	claimed, err := config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": userID, "team_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"team_id": team.ID}},
+	)
+	if err != nil || claimed.ModifiedCount == 0 {
+		_, _ = config.DB.Collection("teams").DeleteOne(ctx, bson.M{"_id": team.ID})
+		c.JSON(http.StatusConflict, gin.H{"error": "Leave your current team first"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team created", "team": team})
+}
+
+// GetTeam
+// GET /teams/:id
+func GetTeam(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	team, ok := loadTeam(ctx, c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, team)
+}
+
+// GetMyTeam returns the caller's team, or null when they are not in one
+// GET /teams/mine
+func GetMyTeam(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var team models.Team
+	err := config.DB.Collection("teams").FindOne(ctx, bson.M{"members.user_id": userID}).Decode(&team)
+	if err == mongo.ErrNoDocuments {
+		c.JSON(http.StatusOK, gin.H{"team": nil})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"team": team})
+}
+
+// InviteToTeam lets the captain invite a player by username
+// POST /teams/:id/invite
+func InviteToTeam(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := c.BindJSON(&req); err != nil || req.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing username"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	team, ok := loadTeam(ctx, c)
+	if !ok {
+		return
+	}
+	if teamRole(team, userID) != "captain" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the captain can invite players"})
+		return
+	}
+
+	var invitee models.User
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"username": req.Username}).Decode(&invitee); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if teamRole(team, invitee.ID) != "" {
+		c.JSON(http.StatusConflict, gin.H{"error": "User is already in this team"})
+		return
+	}
+
+	_, err := config.DB.Collection("teams").UpdateByID(ctx, team.ID, bson.M{"$addToSet": bson.M{"invites": invitee.ID}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to invite user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Invited " + invitee.Username})
+}
+
+// JoinTeam accepts an invite
+// POST /teams/:id/join
+func JoinTeam(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	team, ok := loadTeam(ctx, c)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Collection("users").FindOne(ctx, bson.M{"_id": userID}).Decode(&user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		return
+	}
+
+	claimed, err := config.DB.Collection("users").UpdateOne(ctx,
+		bson.M{"_id": userID, "team_id": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"team_id": team.ID}},
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join team"})
+		return
+	}
+	if claimed.ModifiedCount == 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Leave your current team first"})
+		return
+	}
+
+	res, err := config.DB.Collection("teams").UpdateOne(ctx,
+		bson.M{"_id": team.ID, "invites": userID},
+		bson.M{
+			"$pull": bson.M{"invites": userID},
+			"$push": bson.M{"members": models.TeamMember{
+				UserID:   userID,
+				Username: user.Username,
+				Role:     "member",
+				JoinedAt: time.Now(),
+			}},
+		},
+	)
+	if err != nil || res.ModifiedCount == 0 {
+		_, _ = config.DB.Collection("users").UpdateByID(ctx, userID, bson.M{"$unset": bson.M{"team_id": ""}})
+		c.JSON(http.StatusForbidden, gin.H{"error": "You have not been invited to this team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Joined " + team.Name})
+}
+
+// removeTeamMember takes a player off a team. The last member leaving deletes the team,
+// and a departing captain hands the captaincy to the longest serving member. Both decisions
+// are made on the stored team, so a player joining at the same time is never lost.
+func removeTeamMember(ctx context.Context, team models.Team, userID primitive.ObjectID) error {
+	teams := config.DB.Collection("teams")
+	disband := func(filter bson.M) (bool, error) {
+		res, err := teams.DeleteOne(ctx, filter)
+		if err != nil || res.DeletedCount == 0 {
+			return false, err
+		}
+		_, _ = config.DB.Collection("team_challenges").DeleteMany(ctx, bson.M{"team_id": team.ID})
+		return true, nil
+	}
+
+	disbanded, err := disband(bson.M{"_id": team.ID, "members": bson.M{"$size": 1}, "members.user_id": userID})
+	if err != nil || disbanded {
+		return err
+	}
+
+	var remaining models.Team
+	err = teams.FindOneAndUpdate(ctx,
+		bson.M{"_id": team.ID},
+		bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&remaining)
+	if err == mongo.ErrNoDocuments {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(remaining.Members) == 0 {
+		// Everyone else left at the same time
+		_, err = disband(bson.M{"_id": team.ID, "members": bson.M{"$size": 0}})
+		return err
+	}
+	for _, member := range remaining.Members {
+		if member.Role == "captain" {
+			return nil
+		}
+	}
+	_, err = teams.UpdateOne(ctx,
+		bson.M{"_id": team.ID, "members.user_id": remaining.Members[0].UserID},
+		bson.M{"$set": bson.M{"members.$.role": "captain"}},
+	)
+	if err != nil {
+		fmt.Println("Failed to hand over captaincy:", err)
+	}
+	return nil
+}
+
+// LeaveTeam removes the caller from their team. A leaving captain hands over to the longest
+// serving member, and the last member leaving disbands the team.
+// POST /teams/:id/leave
+func LeaveTeam(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	team, ok := loadTeam(ctx, c)
+	if !ok {
+		return
+	}
+	if teamRole(team, userID) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You are not in this team"})
+		return
+	}
+
+	if err := removeTeamMember(ctx, team, userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave team"})
+		return
+	}
+
+	_, err := config.DB.Collection("users").UpdateByID(ctx, userID, bson.M{"$unset": bson.M{"team_id": ""}})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to leave team"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Left " + team.Name})
+}
+
+// TransferCaptain hands the captain role to another member
+// POST /teams/:id/captain
+func TransferCaptain(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Username string `json:"username"`
+	}
+	if err := c.BindJSON(&req); err != nil || req.Username == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing username"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	team, ok := loadTeam(ctx, c)
+	if !ok {
+		return
+	}
+	if teamRole(team, userID) != "captain" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the captain can hand over the role"})
+		return
+	}
+
+	var newCaptain *models.TeamMember
+	for i, member := range team.Members {
+		if member.Username == req.Username && member.UserID != userID {
+			newCaptain = &team.Members[i]
+		}
+	}
+	if newCaptain == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "That user is not another member of this team"})
+		return
+	}
+
+	teams := config.DB.Collection("teams")
+	_, err := teams.UpdateOne(ctx,
+		bson.M{"_id": team.ID, "members.user_id": newCaptain.UserID},
+		bson.M{"$set": bson.M{"members.$.role": "captain"}},
+	)
+	if err == nil {
+		_, err = teams.UpdateOne(ctx,
+			bson.M{"_id": team.ID, "members.user_id": userID},
+			bson.M{"$set": bson.M{"members.$.role": "member"}},
+		)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to transfer captaincy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": newCaptain.Username + " is now captain"})
+}
+
+// CreateTeamChallenge lets the captain set a shared goal for the team
+// POST /teams/:id/challenges
+func CreateTeamChallenge(c *gin.Context) {
+	userID, _, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Prompt        string `json:"prompt"`
+		Mode          string `json:"mode"`
+		Goal          int    `json:"goal"`
+		DurationHours int    `json:"duration_hours"`
+		BonusPoints   int    `json:"bonus_points"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		return
+	}
+	if strings.TrimSpace(req.Prompt) == "" || req.Goal < 1 || req.DurationHours < 1 || req.BonusPoints < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "prompt is required, goal and duration_hours must be at least 1"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	rules := loadChallengeRules(ctx)
+	if _, ok := rules.Modes[req.Mode]; !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown mode"})
+		return
+	}
+	// The bonus counts on the team leaderboard, so it has to be earned photo by photo
+	if maxBonus := rules.TeamBonusPointsPerGoal * req.Goal; req.BonusPoints > maxBonus {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("bonus_points can be at most %d for a goal of %d", maxBonus, req.Goal)})
+		return
+	}
+
+	team, ok := loadTeam(ctx, c)
+	if !ok {
+		return
+	}
+	if teamRole(team, userID) != "captain" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the captain can create team challenges"})
+		return
+	}
+
+	challenge := models.TeamChallenge{
+		TeamID:       team.ID,
+		Prompt:       strings.TrimSpace(req.Prompt),
+		Mode:         req.Mode,
+		Goal:         req.Goal,
+		Contributors: []primitive.ObjectID{},
+		BonusPoints:  req.BonusPoints,
+		Status:       "active",
+		Deadline:     time.Now().Add(time.Duration(req.DurationHours) * time.Hour),
+		CreatedAt:    time.Now(),
+	}
+	res, err := config.DB.Collection("team_challenges").InsertOne(ctx, challenge)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create team challenge"})
+		return
+	}
+	challenge.ID = res.InsertedID.(primitive.ObjectID)
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team challenge created", "challenge": challenge})
+}
+
+// GetTeamChallenges
+// GET /teams/:id/challenges
+func GetTeamChallenges(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	team, ok := loadTeam(ctx, c)
+	if !ok {
+		return
+	}
+
+	findOptions := options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}})
+	cursor, err := config.DB.Collection("team_challenges").Find(ctx, bson.M{"team_id": team.ID}, findOptions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team challenges"})
+		return
+	}
+
+	challenges := []models.TeamChallenge{}
+	if err := cursor.All(ctx, &challenges); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse team challenges"})
+		return
+	}
+
+	c.JSON(http.StatusOK, challenges)
+}
+
+// AcceptTeamChallenge gives a member an accepted challenge that counts toward the team goal
+// when submitted through /challenge/submit
+// POST /teams/:id/challenges/:challengeId/accept
+func AcceptTeamChallenge(c *gin.Context) {
+	userID, email, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	challengeID, err := primitive.ObjectIDFromHex(c.Param("challengeId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid challenge ID"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	team, ok := loadTeam(ctx, c)
+	if !ok {
+		return
+	}
+	if teamRole(team, userID) == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only team members can take part"})
+		return
+	}
+
+	var teamChallenge models.TeamChallenge
+	err = config.DB.Collection("team_challenges").FindOne(ctx, bson.M{
+		"_id":      challengeID,
+		"team_id":  team.ID,
+		"status":   "active",
+		"deadline": bson.M{"$gt": time.Now()},
+	}).Decode(&teamChallenge)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No open team challenge found"})
+		return
+	}
+
+	userChallenges := config.DB.Collection("user_challenges")
+	count, err := userChallenges.CountDocuments(ctx, bson.M{"email": email, "team_challenge_id": challengeID})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already accepted this team challenge"})
+		return
+	}
+
+	now := time.Now()
+	res, err := userChallenges.InsertOne(ctx, models.UserChallenge{
+		Email:           email,
+		Date:            now.Format("2006-01-02"),
+		Prompt:          teamChallenge.Prompt,
+		Mode:            teamChallenge.Mode,
+		Status:          "accepted",
+		AcceptedAt:      now,
+		TeamChallengeID: &teamChallenge.ID,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save challenge"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Team challenge accepted", "challenge_id": res.InsertedID})
+}
+
+// GetTeamLeaderboard ranks teams by the points their players earned while on the team plus
+// team challenge bonuses, so switching teams doesn't carry a player's history along
+// GET /leaderboard/teams
+func GetTeamLeaderboard(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$addFields", Value: bson.M{
+			"total_score": bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$member_points", 0}},
+				bson.M{"$ifNull": bson.A{"$bonus_points", 0}},
+			}},
+			"member_count": bson.M{"$size": "$members"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"total_score": -1}}},
+	}
+	cursor, err := config.DB.Collection("teams").Aggregate(ctx, pipeline)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch team leaderboard"})
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var rows []struct {
+		TeamID     primitive.ObjectID `bson:"_id"`
+		Name       string             `bson:"name"`
+		Members    int                `bson:"member_count"`
+		TotalScore int                `bson:"total_score"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Decode error"})
+		return
+	}
+
+	leaderboard := []gin.H{}
+	for i, row := range rows {
+		leaderboard = append(leaderboard, gin.H{
+			"rank":        i + 1,
+			"team_id":     row.TeamID.Hex(),
+			"name":        row.Name,
+			"members":     row.Members,
+			"total_score": row.TotalScore,
+		})
+	}
+
+	c.JSON(http.StatusOK, leaderboard)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/joho/godotenv"
 
 	"photoquest/config"
+	"photoquest/controllers"
 	middlewares "photoquest/middleware"
 	"photoquest/routes"
 )
@@ -19,6 +20,9 @@ func main() {
 	}
 
 	config.ConnectDB()
+	config.MigrateLegacyData()
+	config.EnsureIndexes()
+	controllers.ResumeAccountDeletions()
 
 	r := gin.Default()
 	r.Use(cors.New(cors.Config{
@@ -40,6 +44,12 @@ func main() {
 	routes.ChallengeRoutes(protected)
 	routes.GalleryRoutes(protected)
 	routes.LeaderboardRoutes(protected)
+	routes.EventRoutes(protected)
+	routes.HuntRoutes(protected)
+	routes.DuelRoutes(protected)
+	routes.TeamRoutes(protected)
+	routes.GroupRoutes(protected)
+	routes.ModerationRoutes(protected)
 	routes.AdminRoutes(protected)
 
 	r.Run(":8081") // API runs at localhost:8080
@@ -14,4 +14,16 @@ func AdminOnly() gin.HandlerFunc {
 		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// ModeratorOnly lets admins and moderators through
+func ModeratorOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, exists := c.Get("role")
+		if !exists || (role != "admin" && role != "moderator") {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Moderator access only"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AccountDeletionStep records how many documents one step of an account deletion touched.
+type AccountDeletionStep struct {
+	Name       string    `bson:"name" json:"name"`
+	Count      int64     `bson:"count" json:"count"`
+	FinishedAt time.Time `bson:"finished_at" json:"finished_at"`
+}
+
+// AccountDeletion tracks the background job that removes or anonymizes a player's data.
+// The users document is deleted last, so the job can be resumed until it completes.
+type AccountDeletion struct {
+	ID          primitive.ObjectID    `bson:"_id,omitempty" json:"id"`
+	UserID      primitive.ObjectID    `bson:"user_id" json:"user_id"`
+	Status      string                `bson:"status" json:"status"` // queued, running, completed, failed
+	Steps       []AccountDeletionStep `bson:"steps" json:"steps"`
+	Error       string                `bson:"error,omitempty" json:"error,omitempty"`
+	RequestedAt time.Time             `bson:"requested_at" json:"requested_at"`
+	StartedAt   time.Time             `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt  time.Time             `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
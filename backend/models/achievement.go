@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Achievement is a rule in the achievements catalog: it unlocks once the
+// user's value for Metric reaches Threshold.
+type Achievement struct {
+	Key         string `json:"key"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Metric      string `json:"metric"` // uploads, hard_challenges, likes_received, streak_days, correct_guesses
+	Threshold   int    `json:"threshold"`
+}
+
+type UserAchievement struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"-"`
+	UserID     primitive.ObjectID `bson:"user_id" json:"-"`
+	Key        string             `bson:"key" json:"key"`
+	Name       string             `bson:"name" json:"name"`
+	UnlockedAt time.Time          `bson:"unlocked_at" json:"unlocked_at"`
+}
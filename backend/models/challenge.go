@@ -1,22 +1,40 @@
 package models
 
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
 type Challenge struct {
-	Prompt string `bson:"prompt" json:"prompt"`
-	Mode   string `bson:"mode" json:"mode"` // easy, medium, hard
+	Prompt   string    `bson:"prompt" json:"prompt"`
+	Mode     string    `bson:"mode" json:"mode"` // easy, medium, hard
+	Geofence *Geofence `bson:"geofence,omitempty" json:"geofence,omitempty"`
+	Colors   []string  `bson:"colors,omitempty" json:"colors,omitempty"` // target colour names; the photo's palette is checked against them
 }
 
 type UserChallenge struct {
-	Email  string `bson:"email" json:"email"`
-	Date   string `bson:"date" json:"date"` // "YYYY-MM-DD"
-	Prompt string `bson:"prompt" json:"prompt"`
-	Mode   string `bson:"mode" json:"mode"`
-	Status string `bson:"status" json:"status"` // accepted, skipped
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Email           string              `bson:"email" json:"email"`
+	Date            string              `bson:"date" json:"date"` // "YYYY-MM-DD"
+	Prompt          string              `bson:"prompt" json:"prompt"`
+	Mode            string              `bson:"mode" json:"mode"`
+	Status          string              `bson:"status" json:"status"` // accepted, completed, skipped, abandoned, expired
+	AcceptedAt      time.Time           `bson:"accepted_at,omitempty" json:"accepted_at,omitempty"`
+	ClosedAt        time.Time           `bson:"closed_at,omitempty" json:"closed_at,omitempty"`
+	ImageURL        string              `bson:"image_url,omitempty" json:"image_url,omitempty"`
+	EventID         *primitive.ObjectID `bson:"event_id,omitempty" json:"event_id,omitempty"` // set when the prompt came from an event pool
+	DuelID          *primitive.ObjectID `bson:"duel_id,omitempty" json:"duel_id,omitempty"`
+	TeamChallengeID *primitive.ObjectID `bson:"team_challenge_id,omitempty" json:"team_challenge_id,omitempty"`
+	GroupID         *primitive.ObjectID `bson:"group_id,omitempty" json:"group_id,omitempty"` // set for group prompts; the post stays in the group
+	Geofence        *Geofence           `bson:"geofence,omitempty" json:"geofence,omitempty"` // copied from the prompt; the photo must be taken inside it
+	Colors          []string            `bson:"colors,omitempty" json:"colors,omitempty"`     // copied from the prompt
 }
 
 type CustomChallenge struct {
 	Email        string   `bson:"email" json:"email"`
 	ImageURL     string   `bson:"image_url" json:"image_url"`
 	Choices      []string `bson:"choices" json:"choices"`
-	CorrectIndex int      `bson:"correct_index" json:"correct_index"` // 0-3
+	CorrectIndex int      `bson:"correct_index" json:"correct_index"`
 	CreatedAt    string   `bson:"created_at" json:"created_at"`
 }
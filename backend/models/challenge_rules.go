@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+type ModeRules struct {
+	DailyLimit int `bson:"daily_limit" json:"daily_limit"` // challenges of this mode per day
+	Points     int `bson:"points" json:"points"`           // awarded for a completed challenge
+}
+
+// ChallengeRules is stored as a single document in the settings collection and
+// falls back to the defaults in the controllers when it has never been edited.
+type ChallengeRules struct {
+	DailyLimit                int                  `bson:"daily_limit" json:"daily_limit"` // challenges per day across all modes
+	Modes                     map[string]ModeRules `bson:"modes" json:"modes"`             // easy, medium, hard
+	GuessPoints               int                  `bson:"guess_points" json:"guess_points"`
+	GuessTimeLimitSeconds     int                  `bson:"guess_time_limit_seconds" json:"guess_time_limit_seconds"`         // to answer after opening a guess post, 0 for no limit
+	GuessSlowestPointsPercent int                  `bson:"guess_slowest_points_percent" json:"guess_slowest_points_percent"` // of guess_points, for a correct answer right at the time limit
+	GuessHintCostPercent      int                  `bson:"guess_hint_cost_percent" json:"guess_hint_cost_percent"`           // of the guess points, lost by revealing a hint
+	GuessAuthorPoints         int                  `bson:"guess_author_points" json:"guess_author_points"`                   // most an author earns per answer, paid for posts about half the players get right
+	GuessAuthorMinAnswers     int                  `bson:"guess_author_min_answers" json:"guess_author_min_answers"`         // answers a post needs before its author earns anything
+	GuessAuthorPostCap        int                  `bson:"guess_author_post_cap" json:"guess_author_post_cap"`               // most an author earns from one post
+	GuessAuthorDailyCap       int                  `bson:"guess_author_daily_cap" json:"guess_author_daily_cap"`             // most an author earns from answers per day
+	RerollsPerDay             int                  `bson:"rerolls_per_day" json:"rerolls_per_day"`                           // rolls beyond one per daily slot
+	SubmissionWindowHours     int                  `bson:"submission_window_hours" json:"submission_window_hours"`           // after accepting
+	DuelWinPoints             int                  `bson:"duel_win_points" json:"duel_win_points"`
+	DuelVotingHours           int                  `bson:"duel_voting_hours" json:"duel_voting_hours"`
+	TeamBonusPointsPerGoal    int                  `bson:"team_bonus_points_per_goal" json:"team_bonus_points_per_goal"`     // most bonus a captain may set per photo in a team challenge's goal
+	ReviewMode                string               `bson:"review_mode" json:"review_mode"`                                   // off, flagged or all: which submissions are held for a moderator
+	DuplicatePolicy           string               `bson:"duplicate_policy" json:"duplicate_policy"`                         // reject, flag or allow re-used photos
+	DuplicateMaxDistance      int                  `bson:"duplicate_max_distance" json:"duplicate_max_distance"`             // differing hash bits still counted as the same photo, 0-7
+	StalePhotoPointsPercent   int                  `bson:"stale_photo_points_percent" json:"stale_photo_points_percent"`     // of the usual points, for photos taken before accepting
+	UndatedPhotoPointsPercent int                  `bson:"undated_photo_points_percent" json:"undated_photo_points_percent"` // of the usual points, for photos without a capture time
+	ColorReviewPointsPercent  int                  `bson:"color_review_points_percent" json:"color_review_points_percent"`   // of the usual points, for colour prompts the check could only partly confirm
+	ReportHideThreshold       int                  `bson:"report_hide_threshold" json:"report_hide_threshold"`               // open reports that hide a post until a moderator decides, 0 to never auto-hide
+	DeletedPostPoints         string               `bson:"deleted_post_points" json:"deleted_post_points"`                   // revoke or keep the points a post earned its author when it is deleted
+	UpdatedAt                 time.Time            `bson:"updated_at,omitempty" json:"updated_at,omitempty"`
+}
+
+// GuessTimeLimit is how long a player has to answer a guess post, or 0 for no limit.
+func (r ChallengeRules) GuessTimeLimit() time.Duration {
+	return time.Duration(r.GuessTimeLimitSeconds) * time.Second
+}
+
+// SubmissionWindow is how long an accepted challenge stays open for a submission.
+func (r ChallengeRules) SubmissionWindow() time.Duration {
+	return time.Duration(r.SubmissionWindowHours) * time.Hour
+}
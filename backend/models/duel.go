@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Duel pits two players against each other on the same prompt. Each side submits through
+// /challenge/submit and other players then vote for the better shot until VotingEndsAt.
+type Duel struct {
+	ID               primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	ChallengerID     primitive.ObjectID  `bson:"challenger_id" json:"challenger_id"`
+	ChallengerName   string              `bson:"challenger_name" json:"challenger_name"`
+	OpponentID       primitive.ObjectID  `bson:"opponent_id" json:"opponent_id"`
+	OpponentName     string              `bson:"opponent_name" json:"opponent_name"`
+	Prompt           string              `bson:"prompt" json:"prompt"`
+	Mode             string              `bson:"mode" json:"mode"`
+	Status           string              `bson:"status" json:"status"` // pending, declined, active, voting, finished, expired
+	CreatedAt        time.Time           `bson:"created_at" json:"created_at"`
+	SubmitDeadline   time.Time           `bson:"submit_deadline,omitempty" json:"submit_deadline,omitempty"`
+	VotingEndsAt     time.Time           `bson:"voting_ends_at,omitempty" json:"voting_ends_at,omitempty"`
+	ChallengerPostID *primitive.ObjectID `bson:"challenger_post_id,omitempty" json:"challenger_post_id,omitempty"`
+	OpponentPostID   *primitive.ObjectID `bson:"opponent_post_id,omitempty" json:"opponent_post_id,omitempty"`
+	ChallengerVotes  []string            `bson:"challenger_votes" json:"-"` // voter emails, like GalleryPost.Likes
+	OpponentVotes    []string            `bson:"opponent_votes" json:"-"`
+	WinnerID         *primitive.ObjectID `bson:"winner_id,omitempty" json:"winner_id,omitempty"` // unset on a draw
+}
+
+type DuelRecord struct {
+	Wins   int `bson:"wins" json:"wins"`
+	Losses int `bson:"losses" json:"losses"`
+	Draws  int `bson:"draws" json:"draws"`
+}
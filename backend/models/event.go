@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event is a time-limited themed campaign with its own prompt pool. While it runs,
+// RollChallenge draws from Prompts and completed challenges earn PointsMultiplier times
+// the usual points.
+type Event struct {
+	ID               primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name             string             `bson:"name" json:"name"`
+	Description      string             `bson:"description" json:"description"`
+	StartAt          time.Time          `bson:"start_at" json:"start_at"`
+	EndAt            time.Time          `bson:"end_at" json:"end_at"`
+	Prompts          []Challenge        `bson:"prompts" json:"prompts"`
+	PointsMultiplier float64            `bson:"points_multiplier" json:"points_multiplier"`
+	CreatedAt        time.Time          `bson:"created_at" json:"created_at"`
+}
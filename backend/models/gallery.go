@@ -6,17 +6,56 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+type PaletteColor struct {
+	Name  string  `bson:"name" json:"name"`
+	Hex   string  `bson:"hex" json:"hex"`
+	Share float64 `bson:"share" json:"share"` // fraction of the image, 0-1
+}
+
+// GuessRatingStart is the rating new players and guess posts start from.
+const GuessRatingStart = 1200.0
+
 type GalleryPost struct {
-	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	UserID       primitive.ObjectID `bson:"user_id" json:"user_id"`
-	UserName     string             `bson:"user_name" json:"user_name"`
-	UserAvatar   string             `bson:"user_avatar" json:"user_avatar"`
-	ImageURL     string             `bson:"image_url" json:"image_url"`
-	Choices      []string           `bson:"choices,omitempty" json:"choices,omitempty"`
-	CorrectIndex int                `bson:"correct_index,omitempty" json:"correct_index,omitempty"`
-	Task         string             `bson:"task,omitempty" json:"task,omitempty"`
-	Difficulty   string             `bson:"difficulty,omitempty" json:"difficulty,omitempty"`
-	Prompt       string             `bson:"prompt,omitempty" json:"prompt,omitempty"`
-	Likes        []string           `bson:"likes" json:"likes"`
-	CreatedAt    time.Time          `bson:"created_at" json:"created_at"`
+	ID                 primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID             primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	UserName           string              `bson:"user_name" json:"user_name"`
+	UserAvatar         string              `bson:"user_avatar" json:"user_avatar"`
+	ImageURL           string              `bson:"image_url" json:"image_url"`
+	Choices            []string            `bson:"choices,omitempty" json:"-"`                         // only served with a timed round or the answer
+	CorrectIndex       int                 `bson:"correct_index,omitempty" json:"-"`                   // only revealed through correctAnswer
+	Guess              bool                `bson:"guess,omitempty" json:"guess,omitempty"`             // players answer the post; indexed for the guess queues
+	AnswerMode         string              `bson:"answer_mode,omitempty" json:"answer_mode,omitempty"` // text for typed answers; empty for multiple choice
+	Answers            []string            `bson:"answers,omitempty" json:"-"`                         // accepted typed answers, the first one shown as the answer
+	Hint               string              `bson:"hint,omitempty" json:"-"`                            // revealed on request for part of the points
+	Task               string              `bson:"task,omitempty" json:"task,omitempty"`
+	Difficulty         string              `bson:"difficulty,omitempty" json:"difficulty,omitempty"`                   // for guess posts, computed from Rating once enough players answered
+	DeclaredDifficulty string              `bson:"declared_difficulty,omitempty" json:"declared_difficulty,omitempty"` // the guess author's own choice
+	Rating             float64             `bson:"rating,omitempty" json:"rating,omitempty"`                           // guess post Elo rating
+	RatedAnswers       int                 `bson:"rated_answers,omitempty" json:"rated_answers,omitempty"`             // answers that have moved Rating
+	Prompt             string              `bson:"prompt,omitempty" json:"prompt,omitempty"`
+	Caption            string              `bson:"caption,omitempty" json:"caption,omitempty"`
+	ChallengeID        *primitive.ObjectID `bson:"challenge_id,omitempty" json:"challenge_id,omitempty"` // user_challenges record the post answers
+	EventID            *primitive.ObjectID `bson:"event_id,omitempty" json:"event_id,omitempty"`
+	Points             int                 `bson:"points,omitempty" json:"points,omitempty"`               // awarded for the challenge submission
+	AuthorPoints       int                 `bson:"author_points,omitempty" json:"author_points,omitempty"` // earned by the author of a guess post from players' answers
+	HuntRunID          *primitive.ObjectID `bson:"hunt_run_id,omitempty" json:"hunt_run_id,omitempty"`
+	DuelID             *primitive.ObjectID `bson:"duel_id,omitempty" json:"duel_id,omitempty"`
+	GroupID            *primitive.ObjectID `bson:"group_id,omitempty" json:"group_id,omitempty"`           // private group posts are left out of the global gallery
+	Area               *CoarseArea         `bson:"area,omitempty" json:"area,omitempty"`                   // set for geofenced challenges
+	Palette            []PaletteColor      `bson:"palette,omitempty" json:"palette,omitempty"`             // dominant colours, largest first
+	ColorCheck         string              `bson:"color_check,omitempty" json:"color_check,omitempty"`     // verified, needs_review; only for colour prompts
+	Freshness          string              `bson:"freshness,omitempty" json:"freshness,omitempty"`         // fresh, stale or undated: capture time against when the challenge was accepted
+	ReviewStatus       string              `bson:"review_status,omitempty" json:"review_status,omitempty"` // pending, approved, rejected; empty when no review was needed
+	ReviewReason       string              `bson:"review_reason,omitempty" json:"review_reason,omitempty"`
+	ReviewedBy         *primitive.ObjectID `bson:"reviewed_by,omitempty" json:"reviewed_by,omitempty"`
+	ReviewedAt         time.Time           `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	Hidden             bool                `bson:"hidden,omitempty" json:"hidden,omitempty"` // hidden by a moderator or by reaching the report threshold
+	PHash              string              `bson:"phash,omitempty" json:"-"`                 // perceptual hash, hex
+	PHashBands         []string            `bson:"phash_bands,omitempty" json:"-"`
+	DuplicateOf        *primitive.ObjectID `bson:"duplicate_of,omitempty" json:"duplicate_of,omitempty"` // earlier post with a near-identical photo
+	Flags              []string            `bson:"flags,omitempty" json:"flags,omitempty"`               // reasons a moderator should look at the post: duplicate, stale_photo
+	Images             []string            `bson:"images,omitempty" json:"images,omitempty"`             // every photo of a grouped hunt post; ImageURL is the cover
+	Likes              []string            `bson:"likes" json:"likes"`
+	CreatedAt          time.Time           `bson:"created_at" json:"created_at"`
+	EditedAt           time.Time           `bson:"edited_at,omitempty" json:"edited_at,omitempty"`
 }
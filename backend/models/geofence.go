@@ -0,0 +1,17 @@
+package models
+
+// Geofence ties a prompt to a place. Submitted photos must carry EXIF GPS inside it.
+type Geofence struct {
+	Label     string  `bson:"label" json:"label"`
+	Latitude  float64 `bson:"latitude" json:"latitude"`
+	Longitude float64 `bson:"longitude" json:"longitude"`
+	RadiusKm  float64 `bson:"radius_km" json:"radius_km"`
+}
+
+// CoarseArea is the only location stored on a gallery post: the photo's position rounded
+// to about 11 km, plus the geofence label.
+type CoarseArea struct {
+	Label     string  `bson:"label" json:"label"`
+	Latitude  float64 `bson:"latitude" json:"latitude"`
+	Longitude float64 `bson:"longitude" json:"longitude"`
+}
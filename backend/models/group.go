@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type GroupMember struct {
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Username string             `bson:"username" json:"username"`
+	Role     string             `bson:"role" json:"role"` // admin, member
+	JoinedAt time.Time          `bson:"joined_at" json:"joined_at"`
+}
+
+// Group is a private space such as a classroom. Posts tagged with its ID stay out of the
+// global gallery and are only shown to members.
+type Group struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name        string             `bson:"name" json:"name"`
+	Description string             `bson:"description" json:"description"`
+	JoinCode    string             `bson:"join_code" json:"join_code,omitempty"` // only shown to group admins
+	Members     []GroupMember      `bson:"members" json:"members"`
+	CreatedBy   primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// GroupPrompt is a challenge prompt set by a group admin for the group's members.
+type GroupPrompt struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	GroupID   primitive.ObjectID `bson:"group_id" json:"group_id"`
+	Prompt    string             `bson:"prompt" json:"prompt"`
+	Mode      string             `bson:"mode" json:"mode"`
+	Geofence  *Geofence          `bson:"geofence,omitempty" json:"geofence,omitempty"`
+	Colors    []string           `bson:"colors,omitempty" json:"colors,omitempty"`
+	CreatedBy primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
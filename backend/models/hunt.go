@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Hunt is a scavenger hunt template: a list of prompts that must all be photographed
+// within TimeLimitHours of starting. When Ordered is set the steps must be done in order.
+type Hunt struct {
+	ID              primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Title           string             `bson:"title" json:"title"`
+	Description     string             `bson:"description" json:"description"`
+	Steps           []string           `bson:"steps" json:"steps"`
+	Ordered         bool               `bson:"ordered" json:"ordered"`
+	TimeLimitHours  int                `bson:"time_limit_hours" json:"time_limit_hours"`
+	StepPoints      int                `bson:"step_points" json:"step_points"`
+	CompletionBonus int                `bson:"completion_bonus" json:"completion_bonus"`
+	CreatedAt       time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type UserHuntStep struct {
+	Prompt      string              `bson:"prompt" json:"prompt"`
+	ImageURL    string              `bson:"image_url,omitempty" json:"image_url,omitempty"`
+	CompletedAt time.Time           `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	Freshness   string              `bson:"freshness,omitempty" json:"freshness,omitempty"`
+	Flags       []string            `bson:"flags,omitempty" json:"flags,omitempty"`
+	DuplicateOf *primitive.ObjectID `bson:"duplicate_of,omitempty" json:"duplicate_of,omitempty"`
+	PHash       string              `bson:"phash,omitempty" json:"-"`
+	PHashBands  []string            `bson:"phash_bands,omitempty" json:"-"`
+	Points      int                 `bson:"points" json:"points"`
+	Held        bool                `bson:"held,omitempty" json:"held,omitempty"` // points wait for the published post's review
+}
+
+// UserHunt is one player's run through a Hunt.
+type UserHunt struct {
+	ID              primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	HuntID          primitive.ObjectID  `bson:"hunt_id" json:"hunt_id"`
+	UserID          primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Title           string              `bson:"title" json:"title"`
+	Ordered         bool                `bson:"ordered" json:"ordered"`
+	Steps           []UserHuntStep      `bson:"steps" json:"steps"`
+	StepPoints      int                 `bson:"step_points" json:"step_points"` // copied from the hunt when the run starts
+	CompletionBonus int                 `bson:"completion_bonus" json:"completion_bonus"`
+	Status          string              `bson:"status" json:"status"` // active, completed, expired
+	StartedAt       time.Time           `bson:"started_at" json:"started_at"`
+	Deadline        time.Time           `bson:"deadline" json:"deadline"`
+	CompletedAt     time.Time           `bson:"completed_at,omitempty" json:"completed_at,omitempty"`
+	PostID          *primitive.ObjectID `bson:"post_id,omitempty" json:"post_id,omitempty"` // grouped gallery post once published
+}
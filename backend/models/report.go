@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Report is one player's complaint about a post. A player can report a post once.
+type Report struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	PostID     primitive.ObjectID `bson:"post_id" json:"post_id"`
+	ReporterID primitive.ObjectID `bson:"reporter_id" json:"reporter_id"`
+	Reason     string             `bson:"reason" json:"reason"` // offensive, spam, wrong_answer, copyright, other
+	Details    string             `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// ModerationCase collects every open report on a post into one queue entry.
+type ModerationCase struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	PostID         primitive.ObjectID  `bson:"post_id" json:"post_id"`
+	PostOwnerID    primitive.ObjectID  `bson:"post_owner_id" json:"post_owner_id"`
+	Status         string              `bson:"status" json:"status"` // open, resolved
+	ReportCount    int                 `bson:"report_count" json:"report_count"`
+	Reasons        map[string]int      `bson:"reasons" json:"reasons"` // report count per reason
+	AutoHidden     bool                `bson:"auto_hidden,omitempty" json:"auto_hidden,omitempty"`
+	FirstReportAt  time.Time           `bson:"first_report_at" json:"first_report_at"`
+	LastReportAt   time.Time           `bson:"last_report_at" json:"last_report_at"`
+	Action         string              `bson:"action,omitempty" json:"action,omitempty"` // hide, delete, warn, dismiss
+	ResolvedBy     *primitive.ObjectID `bson:"resolved_by,omitempty" json:"resolved_by,omitempty"`
+	ResolvedAt     time.Time           `bson:"resolved_at,omitempty" json:"resolved_at,omitempty"`
+	ResolutionNote string              `bson:"resolution_note,omitempty" json:"resolution_note,omitempty"`
+}
+
+// ModerationLogEntry records a moderation decision. ModeratorID is nil for automatic actions.
+type ModerationLogEntry struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	CaseID      *primitive.ObjectID `bson:"case_id,omitempty" json:"case_id,omitempty"`
+	PostID      primitive.ObjectID  `bson:"post_id" json:"post_id"`
+	PostOwnerID primitive.ObjectID  `bson:"post_owner_id" json:"post_owner_id"`
+	ModeratorID *primitive.ObjectID `bson:"moderator_id,omitempty" json:"moderator_id,omitempty"`
+	Action      string              `bson:"action" json:"action"` // auto_hide, hide, delete, warn, dismiss, edit
+	Note        string              `bson:"note,omitempty" json:"note,omitempty"`
+	CreatedAt   time.Time           `bson:"created_at" json:"created_at"`
+}
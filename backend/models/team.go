@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type TeamMember struct {
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Username string             `bson:"username" json:"username"`
+	Role     string             `bson:"role" json:"role"` // captain, member
+	JoinedAt time.Time          `bson:"joined_at" json:"joined_at"`
+}
+
+// Team is a group of players competing together. A player belongs to at most one team,
+// recorded on the user as team_id.
+type Team struct {
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Name         string               `bson:"name" json:"name"`
+	Members      []TeamMember         `bson:"members" json:"members"`
+	Invites      []primitive.ObjectID `bson:"invites" json:"invites"`             // invited user IDs
+	BonusPoints  int                  `bson:"bonus_points" json:"bonus_points"`   // earned from completed team challenges
+	MemberPoints int                  `bson:"member_points" json:"member_points"` // earned by players while they were on the team
+	CreatedAt    time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// TeamChallenge is a prompt set by a team captain. Each member submission through
+// /challenge/submit adds one to Progress until Goal is reached.
+type TeamChallenge struct {
+	ID           primitive.ObjectID   `bson:"_id,omitempty" json:"id"`
+	TeamID       primitive.ObjectID   `bson:"team_id" json:"team_id"`
+	Prompt       string               `bson:"prompt" json:"prompt"`
+	Mode         string               `bson:"mode" json:"mode"`
+	Goal         int                  `bson:"goal" json:"goal"`
+	Progress     int                  `bson:"progress" json:"progress"`
+	Contributors []primitive.ObjectID `bson:"contributors" json:"contributors"`
+	BonusPoints  int                  `bson:"bonus_points" json:"bonus_points"`
+	Status       string               `bson:"status" json:"status"` // active, completed
+	Deadline     time.Time            `bson:"deadline" json:"deadline"`
+	CreatedAt    time.Time            `bson:"created_at" json:"created_at"`
+}
@@ -8,15 +8,23 @@ type UserStats struct {
 }
 
 type User struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
-	Name       string             `bson:"name" json:"name"`
-	Surname    string             `bson:"surname" json:"surname"`
-	Username   string             `bson:"username" json:"username"`
-	Email      string             `bson:"email" json:"email"`
-	Password   string             `bson:"password" json:"-"`
-	Verified   bool               `bson:"verified" json:"verified"`
-	AvatarURL  string             `bson:"avatar_url" json:"avatar_url"`
-	TotalScore int                `bson:"total_score" json:"total_score"`
-	Role       string             `bson:"role" json:"role"`
-	Stats      *UserStats         `bson:"stats,omitempty" json:"stats,omitempty"`
+	ID          primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	Name        string              `bson:"name" json:"name"`
+	Surname     string              `bson:"surname" json:"surname"`
+	Username    string              `bson:"username" json:"username"`
+	Email       string              `bson:"email" json:"email"`
+	Password    string              `bson:"password" json:"-"`
+	Verified    bool                `bson:"verified" json:"verified"`
+	AvatarURL   string              `bson:"avatar_url" json:"avatar_url"`
+	TotalScore  int                 `bson:"total_score" json:"total_score"`
+	Role        string              `bson:"role" json:"role"`
+	Stats       *UserStats          `bson:"stats,omitempty" json:"stats,omitempty"`
+	DuelRecord  *DuelRecord         `bson:"duel_record,omitempty" json:"duel_record,omitempty"`
+	TeamID      *primitive.ObjectID `bson:"team_id,omitempty" json:"team_id,omitempty"`
+	Warnings    int                 `bson:"warnings,omitempty" json:"warnings,omitempty"`         // moderation warnings received
+	GuessRating float64             `bson:"guess_rating,omitempty" json:"guess_rating,omitempty"` // Elo rating from answering guess posts
+	Deleting    bool                `bson:"deleting,omitempty" json:"-"`                          // locked while the account deletion job runs
+
+	// Achievements is filled in by GetProfile and never stored on the user document
+	Achievements []UserAchievement `bson:"-" json:"achievements,omitempty"`
 }
@@ -2,11 +2,21 @@ package models
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
+// LegacyGuessPoints is charged back for a correct answer from the original answer endpoints,
+// which recorded no points. Those endpoints paid 100 or 20 points and their answers can't be
+// told apart, so the smaller payout is used and no player loses more than an answer earned.
+const LegacyGuessPoints = 20
+
 type UserAnswer struct {
-	ID         primitive.ObjectID `bson:"_id,omitempty"`
-	UserID     primitive.ObjectID `bson:"user_id"`
-	PostID     primitive.ObjectID `bson:"post_id"`
-	Answer     string             `bson:"answer"`
-	IsCorrect  bool               `bson:"is_correct"`
-	AnsweredAt int64              `bson:"answered_at"`
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	UserID        primitive.ObjectID `bson:"user_id"`
+	PostID        primitive.ObjectID `bson:"post_id"`
+	SelectedIndex int                `bson:"selected_index"`
+	Answer        string             `bson:"answer"` // text of the selected choice, or what was typed
+	IsCorrect     bool               `bson:"is_correct"`
+	Points        int                `bson:"points"`              // awarded for the answer; missing on answers from before it was recorded
+	TimedOut      bool               `bson:"timed_out,omitempty"` // answered after the round's time limit
+	HintUsed      bool               `bson:"hint_used,omitempty"`
+	AnswerMs      int64              `bson:"answer_ms,omitempty"` // from the start of the guess round
+	AnsweredAt    int64              `bson:"answered_at"`
 }
@@ -11,5 +11,16 @@ func AdminRoutes(rg *gin.RouterGroup) {
 	admin.Use(middlewares.AdminOnly())
 	{
 		admin.GET("/dashboard", controllers.AdminDashboard)
+		admin.POST("/achievements/backfill", controllers.BackfillAchievements)
+		admin.POST("/posts/phash-backfill", controllers.BackfillPhotoHashes)
+		admin.POST("/self-interactions/cleanup", controllers.RemoveSelfInteractions)
+		admin.PUT("/challenge-rules", controllers.UpdateChallengeRules)
+		admin.PUT("/users/:id/role", controllers.SetUserRole)
+		admin.POST("/challenges", controllers.CreateChallengePrompt)
+		admin.POST("/events", controllers.CreateEvent)
+		admin.PUT("/events/:id", controllers.UpdateEvent)
+		admin.DELETE("/events/:id", controllers.DeleteEvent)
+		admin.POST("/hunts", controllers.CreateHunt)
+		admin.DELETE("/hunts/:id", controllers.DeleteHunt)
 	}
 }
\ No newline at end of file
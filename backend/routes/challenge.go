@@ -9,13 +9,20 @@ import (
 func ChallengeRoutes(rg *gin.RouterGroup) {
 	r := rg.Group("/challenge")
 	{
+		r.GET("/rules", controllers.GetChallengeRules)
 		r.GET("/roll", controllers.RollChallenge)
 		r.GET("/progress", controllers.GetProgress)
 		r.GET("/status", controllers.GetUserChallengeStatus)
 		r.POST("/accept", controllers.AcceptChallenge)
+		r.POST("/skip", controllers.SkipChallenge)
+		r.POST("/abandon", controllers.AbandonChallenge)
+		r.GET("/history", controllers.GetChallengeHistory)
 		r.POST("/upload", controllers.UploadCustomChallenge)
 		r.POST("/submit", controllers.SubmitChallenge)
+		r.GET("/guess/next", controllers.GetNextGuess)
+		r.GET("/guess/for-me", controllers.GetGuessForMe)
 		r.GET("/guess/:id", controllers.GetGuessChallenge)
+		r.POST("/guess/:id/hint", controllers.RevealGuessHint)
 		r.POST("/guess/submit", controllers.SubmitGuessChallenge)
 	}
 }
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"photoquest/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func DuelRoutes(rg *gin.RouterGroup) {
+	r := rg.Group("/duels")
+	{
+		r.GET("", controllers.GetMyDuels)
+		r.POST("", controllers.CreateDuel)
+		r.GET("/voting", controllers.GetVotingDuels)
+		r.GET("/:id", controllers.GetDuel)
+		r.POST("/:id/accept", controllers.AcceptDuel)
+		r.POST("/:id/decline", controllers.DeclineDuel)
+		r.POST("/:id/vote", controllers.VoteDuel)
+	}
+}
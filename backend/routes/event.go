@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"photoquest/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func EventRoutes(rg *gin.RouterGroup) {
+	r := rg.Group("/events")
+	{
+		r.GET("", controllers.GetEvents)
+		r.GET("/:id/leaderboard", controllers.GetEventLeaderboard)
+	}
+}
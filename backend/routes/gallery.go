@@ -14,5 +14,9 @@ func GalleryRoutes(rg *gin.RouterGroup) {
 		r.POST("/share", controllers.ShareGalleryPost)
 		r.POST("/answer", controllers.SubmitAnswer)
 		r.GET("/post/:id", controllers.GetGalleryPostByID)
+		r.PATCH("/post/:id", controllers.EditGalleryPost)
+		r.DELETE("/post/:id", controllers.DeleteGalleryPost)
+		r.GET("/post/:id/stats", controllers.GetGuessPostStats)
+		r.POST("/post/:id/report", controllers.ReportPost)
 	}
 }
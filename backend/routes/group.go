@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"photoquest/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GroupRoutes(rg *gin.RouterGroup) {
+	r := rg.Group("/groups")
+	{
+		r.GET("", controllers.GetMyGroups)
+		r.POST("", controllers.CreateGroup)
+		r.POST("/join", controllers.JoinGroup)
+		r.GET("/:id", controllers.GetGroup)
+		r.POST("/:id/leave", controllers.LeaveGroup)
+		r.POST("/:id/code", controllers.ResetJoinCode)
+		r.PUT("/:id/members/:userId", controllers.SetGroupMemberRole)
+		r.DELETE("/:id/members/:userId", controllers.RemoveGroupMember)
+		r.GET("/:id/prompts", controllers.GetGroupPrompts)
+		r.POST("/:id/prompts", controllers.CreateGroupPrompt)
+		r.DELETE("/:id/prompts/:promptId", controllers.DeleteGroupPrompt)
+		r.POST("/:id/prompts/:promptId/accept", controllers.AcceptGroupPrompt)
+		r.GET("/:id/gallery", controllers.GetGroupGallery)
+		r.GET("/:id/leaderboard", controllers.GetGroupLeaderboard)
+		r.DELETE("/:id/posts/:postId", controllers.DeleteGroupPost)
+	}
+}
@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"photoquest/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func HuntRoutes(rg *gin.RouterGroup) {
+	r := rg.Group("/hunts")
+	{
+		r.GET("", controllers.GetHunts)
+		r.POST("/:id/start", controllers.StartHunt)
+		r.GET("/runs", controllers.GetMyHuntRuns)
+		r.GET("/runs/:runId", controllers.GetHuntRun)
+		r.POST("/runs/:runId/steps/:step", controllers.SubmitHuntStep)
+		r.POST("/runs/:runId/publish", controllers.PublishHuntRun)
+	}
+}
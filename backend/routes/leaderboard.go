@@ -7,4 +7,5 @@ import (
 
 func LeaderboardRoutes(rg *gin.RouterGroup) {
 	rg.GET("/leaderboard", controllers.GetLeaderboard)
+	rg.GET("/leaderboard/teams", controllers.GetTeamLeaderboard)
 }
\ No newline at end of file
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"photoquest/controllers"
+	"photoquest/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func ModerationRoutes(rg *gin.RouterGroup) {
+	r := rg.Group("/moderation")
+	r.Use(middlewares.ModeratorOnly())
+	{
+		r.GET("/reports", controllers.GetModerationQueue)
+		r.GET("/reports/:id", controllers.GetModerationCase)
+		r.POST("/reports/:id/action", controllers.ResolveModerationCase)
+		r.GET("/log", controllers.GetModerationLog)
+		r.GET("/posts/flagged", controllers.GetFlaggedPosts)
+		r.GET("/reviews", controllers.GetReviewQueue)
+		r.POST("/reviews/:id/approve", controllers.ApproveSubmission)
+		r.POST("/reviews/:id/reject", controllers.RejectSubmission)
+	}
+}
@@ -10,7 +10,11 @@ func ProfileRoutes(group *gin.RouterGroup) {
 	group.Use(middlewares.JWTAuthMiddleware()) // ✅ correct
 	
 	group.GET("/profile", controllers.GetProfile)
+	group.GET("/profile/achievements", controllers.GetMyAchievements)
+	group.GET("/profile/guess-stats", controllers.GetMyGuessStats)
 	group.PUT("/profile", controllers.UpdateProfile)
 	group.POST("/profile/upload", controllers.UploadAvatar)
 	group.DELETE("/profile", controllers.DeleteAccount)
+	group.GET("/profile/deletion", controllers.GetAccountDeletion)
+	group.GET("/profile/export", controllers.ExportAccount)
 }
\ No newline at end of file
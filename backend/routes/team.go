@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"photoquest/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TeamRoutes(rg *gin.RouterGroup) {
+	r := rg.Group("/teams")
+	{
+		r.POST("", controllers.CreateTeam)
+		r.GET("/mine", controllers.GetMyTeam)
+		r.GET("/:id", controllers.GetTeam)
+		r.POST("/:id/invite", controllers.InviteToTeam)
+		r.POST("/:id/join", controllers.JoinTeam)
+		r.POST("/:id/leave", controllers.LeaveTeam)
+		r.POST("/:id/captain", controllers.TransferCaptain)
+		r.GET("/:id/challenges", controllers.GetTeamChallenges)
+		r.POST("/:id/challenges", controllers.CreateTeamChallenge)
+		r.POST("/:id/challenges/:challengeId/accept", controllers.AcceptTeamChallenge)
+	}
+}
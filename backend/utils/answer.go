@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// NormalizeAnswer reduces a typed answer to the form answers are compared in: lower case,
+// letters and digits only, single spaces, and no leading article.
+func NormalizeAnswer(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	words := strings.Fields(b.String())
+	if len(words) > 1 {
+		switch words[0] {
+		case "the", "a", "an":
+			words = words[1:]
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// AnswerMatches reports whether a typed answer matches any accepted answer after
+// normalizing, allowing a typo or two in the words of longer answers. Words with digits in
+// them, such as years and counts, have to match exactly.
+func AnswerMatches(given string, accepted []string) bool {
+	given = NormalizeAnswer(given)
+	if given == "" {
+		return false
+	}
+	givenWords, givenNumbers := splitNumbers(given)
+	for _, answer := range accepted {
+		answer = NormalizeAnswer(answer)
+		if answer == "" {
+			continue
+		}
+		words, numbers := splitNumbers(answer)
+		if numbers != givenNumbers {
+			continue
+		}
+		if editDistance(givenWords, words) <= typoAllowance(words) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitNumbers separates a normalized answer into its words without digits and its words
+// with digits, each joined by single spaces.
+func splitNumbers(answer string) (words, numbers string) {
+	var plain, numeric []string
+	for _, word := range strings.Fields(answer) {
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			numeric = append(numeric, word)
+		} else {
+			plain = append(plain, word)
+		}
+	}
+	return strings.Join(plain, " "), strings.Join(numeric, " ")
+}
+
+// typoAllowance is how many edits an answer of this length tolerates.
+func typoAllowance(answer string) int {
+	switch n := len([]rune(answer)); {
+	case n <= 3:
+		return 0
+	case n <= 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// editDistance is the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
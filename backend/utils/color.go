@@ -0,0 +1,154 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+	"sort"
+	"strings"
+
+	"photoquest/models"
+)
+
+// ColorNames are the buckets every pixel is sorted into. Prompts and searches use these names.
+var ColorNames = []string{"red", "orange", "yellow", "green", "blue", "purple", "pink", "brown", "black", "white", "gray"}
+
+// Images are sampled on a grid of about this many points per side, which is plenty to find
+// dominant colours and keeps large photos cheap.
+const paletteSampleSide = 120
+
+// DominantColors decodes a JPEG, PNG or GIF and returns its colour buckets, largest first,
+// each with the average colour of its pixels. Buckets covering less than minShare of the
+// image are dropped.
+func DominantColors(data []byte, minShare float64) ([]models.PaletteColor, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	bounds := img.Bounds()
+	stepX := max(bounds.Dx()/paletteSampleSide, 1)
+	stepY := max(bounds.Dy()/paletteSampleSide, 1)
+
+	type bucket struct {
+		r, g, b, count int
+	}
+	buckets := map[string]*bucket{}
+	total := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += stepY {
+		for x := bounds.Min.X; x < bounds.Max.X; x += stepX {
+			r32, g32, b32, _ := img.At(x, y).RGBA()
+			r, g, b := uint8(r32>>8), uint8(g32>>8), uint8(b32>>8)
+
+			name := ColorName(r, g, b)
+			bk, ok := buckets[name]
+			if !ok {
+				bk = &bucket{}
+				buckets[name] = bk
+			}
+			bk.r += int(r)
+			bk.g += int(g)
+			bk.b += int(b)
+			bk.count++
+			total++
+		}
+	}
+	if total == 0 {
+		return nil, fmt.Errorf("image is empty")
+	}
+
+	palette := []models.PaletteColor{}
+	for name, bk := range buckets {
+		share := float64(bk.count) / float64(total)
+		if share < minShare {
+			continue
+		}
+		palette = append(palette, models.PaletteColor{
+			Name:  name,
+			Hex:   fmt.Sprintf("#%02x%02x%02x", bk.r/bk.count, bk.g/bk.count, bk.b/bk.count),
+			Share: math.Round(share*1000) / 1000,
+		})
+	}
+	sort.Slice(palette, func(i, j int) bool { return palette[i].Share > palette[j].Share })
+	return palette, nil
+}
+
+// ColorName sorts an RGB colour into one of ColorNames using its hue, saturation and lightness.
+func ColorName(r, g, b uint8) string {
+	rf, gf, bf := float64(r)/255, float64(g)/255, float64(b)/255
+	maxC := math.Max(rf, math.Max(gf, bf))
+	minC := math.Min(rf, math.Min(gf, bf))
+	lightness := (maxC + minC) / 2
+	delta := maxC - minC
+
+	if lightness < 0.12 {
+		return "black"
+	}
+	if lightness > 0.92 {
+		return "white"
+	}
+	saturation := delta / (1 - math.Abs(2*lightness-1))
+	if saturation < 0.15 {
+		return "gray"
+	}
+
+	var hue float64
+	switch maxC {
+	case rf:
+		hue = math.Mod((gf-bf)/delta, 6)
+	case gf:
+		hue = (bf-rf)/delta + 2
+	default:
+		hue = (rf-gf)/delta + 4
+	}
+	hue *= 60
+	if hue < 0 {
+		hue += 360
+	}
+
+	switch {
+	case hue < 15 || hue >= 345:
+		return "red"
+	case hue < 45:
+		if lightness < 0.4 {
+			return "brown"
+		}
+		return "orange"
+	case hue < 70:
+		return "yellow"
+	case hue < 170:
+		return "green"
+	case hue < 260:
+		return "blue"
+	case hue < 320:
+		return "purple"
+	default:
+		return "pink"
+	}
+}
+
+// ParseColorName accepts a colour name from ColorNames or a "#rrggbb" hex value and returns
+// its bucket name.
+func ParseColorName(s string) (string, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "grey" {
+		s = "gray"
+	}
+	for _, name := range ColorNames {
+		if s == name {
+			return name, true
+		}
+	}
+
+	var r, g, b uint8
+	if len(s) == 7 {
+		if n, err := fmt.Sscanf(s, "#%02x%02x%02x", &r, &g, &b); err == nil && n == 3 {
+			return ColorName(r, g, b), true
+		}
+	}
+	return "", false
+}
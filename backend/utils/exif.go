@@ -0,0 +1,294 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PhotoMetadata is what we read from a JPEG's EXIF block.
+type PhotoMetadata struct {
+	HasLocation bool
+	Latitude    float64
+	Longitude   float64
+	TakenAt     time.Time // zero when the photo carries no capture time
+	// TakenAtExact is false when the camera recorded local time without a UTC offset,
+	// in which case TakenAt was read as UTC and can be off by up to 14 hours.
+	TakenAtExact bool
+}
+
+var ErrNoEXIF = errors.New("photo has no EXIF data")
+
+const (
+	tagDateTime           = 0x0132
+	tagExifIFD            = 0x8769
+	tagGPSIFD             = 0x8825
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagGPSLatitudeRef     = 0x0001
+	tagGPSLatitude        = 0x0002
+	tagGPSLongitudeRef    = 0x0003
+	tagGPSLongitude       = 0x0004
+	tagGPSTimeStamp       = 0x0007
+	tagGPSDateStamp       = 0x001d
+)
+
+// ReadPhotoMetadata extracts GPS position and capture time from JPEG data.
+func ReadPhotoMetadata(data []byte) (PhotoMetadata, error) {
+	var meta PhotoMetadata
+
+	tiff, _, _, ok := findEXIF(data)
+	if !ok {
+		return meta, ErrNoEXIF
+	}
+	t, err := newTIFFReader(tiff)
+	if err != nil {
+		return meta, err
+	}
+
+	ifd0, err := t.readIFD(t.firstIFD)
+	if err != nil {
+		return meta, err
+	}
+
+	var exif, gps map[uint16]tiffEntry
+	if e, ok := ifd0[tagExifIFD]; ok {
+		if exif, err = t.readIFD(t.long(e)); err != nil {
+			return meta, err
+		}
+	}
+	if e, ok := ifd0[tagGPSIFD]; ok {
+		if gps, err = t.readIFD(t.long(e)); err != nil {
+			return meta, err
+		}
+	}
+
+	if gps != nil {
+		lat, latOK := t.degrees(gps[tagGPSLatitude])
+		lng, lngOK := t.degrees(gps[tagGPSLongitude])
+		if latOK && lngOK {
+			if strings.HasPrefix(t.ascii(gps[tagGPSLatitudeRef]), "S") {
+				lat = -lat
+			}
+			if strings.HasPrefix(t.ascii(gps[tagGPSLongitudeRef]), "W") {
+				lng = -lng
+			}
+			meta.HasLocation = true
+			meta.Latitude = lat
+			meta.Longitude = lng
+		}
+
+		// The GPS clock is UTC, so prefer it over the camera clock
+		stamp, stampOK := t.rationals(gps[tagGPSTimeStamp])
+		date, err := time.Parse("2006:01:02", t.ascii(gps[tagGPSDateStamp]))
+		if stampOK && len(stamp) == 3 && err == nil {
+			meta.TakenAt = date.Add(time.Duration(stamp[0]*float64(time.Hour) + stamp[1]*float64(time.Minute) + stamp[2]*float64(time.Second)))
+			meta.TakenAtExact = true
+			return meta, nil
+		}
+	}
+
+	original := t.ascii(exif[tagDateTimeOriginal])
+	if original == "" {
+		original = t.ascii(ifd0[tagDateTime])
+	}
+	if original != "" {
+		if offset := t.ascii(exif[tagOffsetTimeOriginal]); offset != "" {
+			if taken, err := time.Parse("2006:01:02 15:04:05-07:00", original+offset); err == nil {
+				meta.TakenAt = taken
+				meta.TakenAtExact = true
+				return meta, nil
+			}
+		}
+		if taken, err := time.Parse("2006:01:02 15:04:05", original); err == nil {
+			meta.TakenAt = taken
+		}
+	}
+
+	return meta, nil
+}
+
+// StripEXIF returns the JPEG without its APP1 segments, so location and camera details are
+// not published with the image. That covers every Exif block and the XMP packet, which can
+// carry GPS too. Data that isn't a JPEG is returned unchanged.
+func StripEXIF(data []byte) []byte {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return data
+	}
+	stripped := make([]byte, 0, len(data))
+	stripped = append(stripped, data[:2]...)
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			break
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 { // image data starts, no more metadata
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			// Malformed segment: keep the original rather than guess where the image starts
+			return data
+		}
+		if marker != 0xE1 {
+			stripped = append(stripped, data[pos:pos+2+length]...)
+		}
+		pos += 2 + length
+	}
+	return append(stripped, data[pos:]...)
+}
+
+// findEXIF locates the APP1 Exif segment in a JPEG. It returns the TIFF payload and the
+// byte range of the whole segment including its marker.
+func findEXIF(data []byte) (tiff []byte, start, end int, ok bool) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, 0, 0, false
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, 0, 0, false
+		}
+		marker := data[pos+1]
+		if marker == 0xDA || marker == 0xD9 { // image data starts, no more metadata
+			return nil, 0, 0, false
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil, 0, 0, false
+		}
+		payload := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(payload, []byte("Exif\x00\x00")) {
+			return payload[6:], pos, pos + 2 + length, true
+		}
+		pos += 2 + length
+	}
+	return nil, 0, 0, false
+}
+
+type tiffEntry struct {
+	typ   uint16
+	count uint32
+	value []byte // the 4 inline bytes, or the offset to the data
+}
+
+type tiffReader struct {
+	data     []byte
+	order    binary.ByteOrder
+	firstIFD uint32
+}
+
+func newTIFFReader(data []byte) (*tiffReader, error) {
+	if len(data) < 8 {
+		return nil, errors.New("EXIF header too short")
+	}
+	t := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return nil, errors.New("invalid EXIF byte order")
+	}
+	if t.order.Uint16(data[2:]) != 42 {
+		return nil, errors.New("invalid EXIF header")
+	}
+	t.firstIFD = t.order.Uint32(data[4:])
+	return t, nil
+}
+
+func (t *tiffReader) readIFD(offset uint32) (map[uint16]tiffEntry, error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, fmt.Errorf("EXIF directory offset %d out of range", offset)
+	}
+	count := int(t.order.Uint16(t.data[offset:]))
+	start := int(offset) + 2
+	if start+count*12 > len(t.data) {
+		return nil, errors.New("EXIF directory truncated")
+	}
+
+	entries := make(map[uint16]tiffEntry, count)
+	for i := 0; i < count; i++ {
+		raw := t.data[start+i*12:]
+		entries[t.order.Uint16(raw)] = tiffEntry{
+			typ:   t.order.Uint16(raw[2:]),
+			count: t.order.Uint32(raw[4:]),
+			value: raw[8:12],
+		}
+	}
+	return entries, nil
+}
+
+// bytes returns the entry's raw value, following the offset when it does not fit inline.
+func (t *tiffReader) bytes(e tiffEntry) []byte {
+	var size uint64
+	switch e.typ {
+	case 1, 2, 7: // byte, ascii, undefined
+		size = 1
+	case 3: // short
+		size = 2
+	case 4, 9: // long, slong
+		size = 4
+	case 5, 10: // rational, srational
+		size = 8
+	default:
+		return nil
+	}
+	total := size * uint64(e.count)
+	if total <= 4 {
+		return e.value[:total]
+	}
+	offset := uint64(t.order.Uint32(e.value))
+	if offset+total > uint64(len(t.data)) {
+		return nil
+	}
+	return t.data[offset : offset+total]
+}
+
+func (t *tiffReader) long(e tiffEntry) uint32 {
+	if e.typ == 3 {
+		return uint32(t.order.Uint16(e.value))
+	}
+	return t.order.Uint32(e.value)
+}
+
+func (t *tiffReader) ascii(e tiffEntry) string {
+	if e.typ != 2 {
+		return ""
+	}
+	return strings.TrimRight(string(t.bytes(e)), "\x00 ")
+}
+
+func (t *tiffReader) rationals(e tiffEntry) ([]float64, bool) {
+	if e.typ != 5 {
+		return nil, false
+	}
+	raw := t.bytes(e)
+	if raw == nil {
+		return nil, false
+	}
+	values := make([]float64, 0, e.count)
+	for i := 0; i+8 <= len(raw); i += 8 {
+		num := t.order.Uint32(raw[i:])
+		den := t.order.Uint32(raw[i+4:])
+		if den == 0 {
+			return nil, false
+		}
+		values = append(values, float64(num)/float64(den))
+	}
+	return values, true
+}
+
+// degrees converts a degrees/minutes/seconds GPS value to decimal degrees.
+func (t *tiffReader) degrees(e tiffEntry) (float64, bool) {
+	dms, ok := t.rationals(e)
+	if !ok || len(dms) != 3 {
+		return 0, false
+	}
+	return dms[0] + dms[1]/60 + dms[2]/3600, true
+}
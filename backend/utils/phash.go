@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math/bits"
+	"strconv"
+)
+
+// PerceptualHashBands is how many 8-bit bands a hash is split into for lookups. Two hashes
+// that differ in fewer bits than this share at least one identical band.
+const PerceptualHashBands = 8
+
+// PerceptualHash returns a 64-bit difference hash of a JPEG, PNG or GIF. Re-encoded, resized
+// or slightly edited copies of a photo hash to values a few bits apart.
+func PerceptualHash(data []byte) (uint64, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	// Shrink to a 9x8 grayscale grid by averaging, then compare each cell with its right neighbour
+	const width, height = 9, 8
+	var grid [height][width]float64
+	bounds := img.Bounds()
+	if bounds.Dx() < width || bounds.Dy() < height {
+		return 0, fmt.Errorf("image is too small to hash")
+	}
+	for gy := 0; gy < height; gy++ {
+		y0 := bounds.Min.Y + gy*bounds.Dy()/height
+		y1 := bounds.Min.Y + (gy+1)*bounds.Dy()/height
+		for gx := 0; gx < width; gx++ {
+			x0 := bounds.Min.X + gx*bounds.Dx()/width
+			x1 := bounds.Min.X + (gx+1)*bounds.Dx()/width
+
+			// Sample at most 16x16 points per cell to keep large photos cheap
+			stepX := max((x1-x0)/16, 1)
+			stepY := max((y1-y0)/16, 1)
+			sum, count := 0.0, 0
+			for y := y0; y < y1; y += stepY {
+				for x := x0; x < x1; x += stepX {
+					r, g, b, _ := img.At(x, y).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(b)
+					count++
+				}
+			}
+			grid[gy][gx] = sum / float64(count)
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < height; y++ {
+		for x := 0; x < width-1; x++ {
+			hash <<= 1
+			if grid[y][x] < grid[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash, nil
+}
+
+// HashDistance is the number of bits two perceptual hashes differ in.
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// FormatHash and ParseHash store hashes as hex, since MongoDB has no unsigned 64-bit type.
+func FormatHash(hash uint64) string {
+	return fmt.Sprintf("%016x", hash)
+}
+
+func ParseHash(s string) (uint64, error) {
+	return strconv.ParseUint(s, 16, 64)
+}
+
+// HashBands splits a hash into position-tagged bands, such as "3:a7", for an indexed
+// candidate lookup.
+func HashBands(hash uint64) []string {
+	bands := make([]string, PerceptualHashBands)
+	for i := range bands {
+		bands[i] = fmt.Sprintf("%d:%02x", i, byte(hash>>(8*i)))
+	}
+	return bands
+}
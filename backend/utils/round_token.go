@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Round tokens are signed with their own key derived from JWT_SECRET, so a round token can
+// never be passed off as a login token or the other way round.
+func roundTokenKey() []byte {
+	return []byte(os.Getenv("JWT_SECRET") + ":guess-round")
+}
+
+// GenerateRoundToken signs the start of a player's guess round on a post
+func GenerateRoundToken(userID, postID string, startedAt time.Time) (string, error) {
+	claims := jwt.MapClaims{
+		"player":     userID,
+		"post":       postID,
+		"started_at": startedAt.UnixMilli(),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(roundTokenKey())
+}
+
+// ParseRoundToken checks a round token's signature and returns what it was issued for
+func ParseRoundToken(tokenString string) (userID, postID string, startedAt time.Time, err error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return roundTokenKey(), nil
+	})
+	if err != nil {
+		return "", "", time.Time{}, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return "", "", time.Time{}, fmt.Errorf("invalid round token claims")
+	}
+	userID, _ = claims["player"].(string)
+	postID, _ = claims["post"].(string)
+	started, _ := claims["started_at"].(float64)
+	if userID == "" || postID == "" || started == 0 {
+		return "", "", time.Time{}, fmt.Errorf("incomplete round token")
+	}
+	return userID, postID, time.UnixMilli(int64(started)), nil
+}
@@ -1,10 +1,13 @@
 package utils
 
 import (
+	"bytes"
 	"context"
+	"crypto/rand"
 	"fmt"
 	"mime/multipart"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -31,7 +34,13 @@ func UploadToS3(file multipart.File, fileHeader *multipart.FileHeader) (string,
 
 	client := s3.NewFromConfig(cfg)
 
-	filename := fmt.Sprintf("%d_%s", time.Now().Unix(), fileHeader.Filename)
+	// The random part keeps two uploads of the same file in the same second apart, so
+	// deleting one never removes the other
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", fmt.Errorf("failed to name upload: %v", err)
+	}
+	filename := fmt.Sprintf("%d_%x_%s", time.Now().Unix(), suffix, fileHeader.Filename)
 	_, err = client.PutObject(context.TODO(), &s3.PutObjectInput{
 		Bucket:      aws.String(bucket),
 		Key:         aws.String(filename),
@@ -46,4 +55,51 @@ func UploadToS3(file multipart.File, fileHeader *multipart.FileHeader) (string,
 	// Return public S3 URL
 	url := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, filename)
 	return url, nil
-}
\ No newline at end of file
+}
+
+// memoryFile lets in-memory data go through UploadToS3.
+type memoryFile struct {
+	*bytes.Reader
+}
+
+func (memoryFile) Close() error { return nil }
+
+// UploadBytesToS3 uploads data that was already read and possibly rewritten, such as a
+// photo with its EXIF block stripped.
+func UploadBytesToS3(data []byte, fileHeader *multipart.FileHeader) (string, error) {
+	return UploadToS3(memoryFile{bytes.NewReader(data)}, fileHeader)
+}
+
+// DeleteFromS3 removes an object uploaded by UploadToS3, given its public URL. URLs outside
+// the bucket are left alone.
+func DeleteFromS3(url string) error {
+	region := os.Getenv("AWS_REGION")
+	bucket := os.Getenv("AWS_BUCKET_NAME")
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+
+	prefix := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/", bucket, region)
+	if !strings.HasPrefix(url, prefix) || len(url) == len(prefix) {
+		return fmt.Errorf("not an object in this bucket: %s", url)
+	}
+	// UploadToS3 builds URLs from the raw key, so the key is everything after the prefix
+	key := strings.TrimPrefix(url, prefix)
+
+	cfg, err := config.LoadDefaultConfig(context.TODO(),
+		config.WithRegion(region),
+		config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(accessKey, secretKey, "")),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to load AWS config: %v", err)
+	}
+
+	client := s3.NewFromConfig(cfg)
+	_, err = client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete from S3: %v", err)
+	}
+	return nil
+}